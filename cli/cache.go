@@ -0,0 +1,192 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+// version is the version of md-to-godoc, which may be set when building with
+// -ldflags "-X main.version=v1.2.3".
+var version = ""
+
+// userCacheDir returns the directory the cache is kept in, below the user's
+// cache directory.
+var userCacheDir = func() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "md-to-godoc"), nil
+}
+
+// update renders the input and writes it to the output, unless the cache
+// shows that neither the output nor anything it's made from has changed since
+// it was last written. It returns what happened to the output: create,
+// modify, unchanged or cached. Outputs rendered with warnings aren't cached,
+// so that the warnings are repeated until they're dealt with.
+func (c *config) update(stdin io.Reader) (string, error) {
+	docs, opts, err := c.renderOptions(stdin)
+	if err != nil {
+		return "", err
+	}
+	path := c.outputPath()
+	key := cacheKey(path, docs, opts)
+	if !c.force && cached(path, key) {
+		return "cached", nil
+	}
+
+	warned := false
+	warnf := opts.Warnf
+	opts.Warnf = func(format string, args ...interface{}) {
+		warned = true
+		warnf(format, args...)
+	}
+	output, err := render.GenerateDocuments(docs, opts)
+	if err != nil {
+		return "", err
+	}
+	p := &pending{path: path, output: output}
+	p.current, err = ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	status := p.status()
+	if status != "unchanged" {
+		if err := writeFile(path, output); err != nil {
+			return "", err
+		}
+	}
+	// The cache only saves time, so failing to write it isn't an error
+	if !warned {
+		storeCache(path, key, output)
+	}
+	return status, nil
+}
+
+// cacheKey hashes everything the output is made from: the inputs, the
+// options they're rendered with, including the license, and the version of
+// the tool.
+func cacheKey(path string, docs []render.Document, opts render.Options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", toolVersion(), path)
+	for _, doc := range docs {
+		demote := "default"
+		if doc.DemoteHeadings != nil {
+			demote = fmt.Sprint(*doc.DemoteHeadings)
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00", doc.Name, doc.Dir, demote, len(doc.Markdown))
+		h.Write(doc.Markdown)
+	}
+	h.Write(opts.License)
+	h.Write([]byte{0})
+	for _, re := range opts.Sections.Include {
+		fmt.Fprintf(h, "include %s\n", re)
+	}
+	for _, re := range opts.Sections.Exclude {
+		fmt.Fprintf(h, "exclude %s\n", re)
+	}
+	// What's left prints the same every time
+	opts.License = nil
+	opts.Sections = render.SectionFilter{}
+	opts.Warnf = nil
+	fmt.Fprintf(h, "%+v", opts)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// toolVersion identifies the build of md-to-godoc, so that upgrading it
+// invalidates the cache.
+func toolVersion() string {
+	v := version
+	if info, ok := debug.ReadBuildInfo(); ok {
+		v += " " + info.Main.Version
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" || s.Key == "vcs.modified" {
+				v += " " + s.Value
+			}
+		}
+	}
+	// Development builds all look the same, but are rebuilt
+	if exe, err := os.Executable(); err == nil {
+		if fi, err := os.Stat(exe); err == nil {
+			v += " " + fi.ModTime().String()
+		}
+	}
+	return v
+}
+
+// cacheFile returns the file the cache entry for the output at path is kept
+// in.
+func cacheFile(path string) (string, error) {
+	dir, err := userCacheDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])), nil
+}
+
+// cached returns true if the output at path was last written for key, and
+// hasn't been changed since.
+func cached(path, key string) bool {
+	file, err := cacheFile(path)
+	if err != nil {
+		return false
+	}
+	entry, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(entry))
+	if len(fields) != 2 || fields[0] != key {
+		return false
+	}
+	current, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return fields[1] == hashBytes(current)
+}
+
+// storeCache records that output was written to path for key.
+func storeCache(path, key string, output []byte) {
+	file, err := cacheFile(path)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return
+	}
+	ioutil.WriteFile(file, []byte(key+" "+hashBytes(output)+"\n"), 0644)
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the cache of the tests out of the user's
+	dir, err := ioutil.TempDir("", "md-to-godoc-cache")
+	if err != nil {
+		panic(err)
+	}
+	userCacheDir = func() (string, error) { return dir, nil }
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestUpdate_Cache(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	input := filepath.Join(dir, "README.md")
+	output := filepath.Join(dir, "doc.go")
+	require.NoError(t, ioutil.WriteFile(input, []byte("Text.\n"), 0644))
+
+	update := func(args ...string) string {
+		c := parseConfig(t, append([]string{"-input", input, "-pkg", "foo"}, args...)...)
+		return updateStatus(t, c)
+	}
+	force := func() string {
+		c := parseConfig(t, "-input", input, "-pkg", "foo")
+		c.force = true
+		return updateStatus(t, c)
+	}
+	assert.Equal(t, "create", update())
+	assert.Equal(t, "cached", update())
+	assert.Equal(t, "unchanged", force())
+
+	// Changes to the options, the input or the output all count
+	assert.Equal(t, "unchanged", update("-badges"))
+	assert.Equal(t, "modify", update("-pkg", "bar"))
+	assert.Equal(t, "modify", update())
+	require.NoError(t, ioutil.WriteFile(input, []byte("Other text.\n"), 0644))
+	assert.Equal(t, "modify", update())
+	require.NoError(t, ioutil.WriteFile(output, []byte("package foo\n"), 0644))
+	assert.Equal(t, "modify", update())
+	assert.Equal(t, "cached", update())
+}
+
+func TestUpdate_CacheWarnings(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	input := filepath.Join(dir, "README.md")
+	require.NoError(t, ioutil.WriteFile(input, []byte("No period\n"), 0644))
+
+	for _, status := range []string{"create", "unchanged"} {
+		var warnings bytes.Buffer
+		c := parseConfig(t, "-input", input, "-pkg", "foo")
+		c.warnings = &warnings
+		assert.Equal(t, status, updateStatus(t, c))
+		assert.Contains(t, warnings.String(), "doesn't end with a period")
+	}
+}
+
+func updateStatus(t *testing.T, c *config) string {
+	status, err := c.update(nil)
+	require.NoError(t, err)
+	return status
+}
+
+func TestRunGen_Force(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	input := filepath.Join(dir, "README.md")
+	require.NoError(t, ioutil.WriteFile(input, []byte("Text.\n"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, Run([]string{"-input", input, "-pkg", "foo"}, nil, &stdout, &stderr))
+	assert.Equal(t, 0, Run([]string{"-input", input, "-pkg", "foo", "-force"}, nil, &stdout, &stderr))
+	assert.Empty(t, stderr.String())
+}
+
+func TestCacheKey(t *testing.T) {
+	docs := func(texts ...string) []render.Document {
+		var docs []render.Document
+		for _, text := range texts {
+			docs = append(docs, render.Document{Name: "README.md", Markdown: []byte(text)})
+		}
+		return docs
+	}
+	opts := render.Options{Package: "foo", License: []byte("MIT")}
+	key := cacheKey("doc.go", docs("Text."), opts)
+	assert.Equal(t, key, cacheKey("doc.go", docs("Text."), opts))
+
+	assert.NotEqual(t, key, cacheKey("other.go", docs("Text."), opts))
+	assert.NotEqual(t, key, cacheKey("doc.go", docs("Text!"), opts))
+	assert.NotEqual(t, key, cacheKey("doc.go", docs("Text", "."), opts))
+	moved := docs("Text.")
+	moved[0].Dir = "docs"
+	assert.NotEqual(t, key, cacheKey("doc.go", moved, opts))
+	demote := render.Demotion(2)
+	moved = docs("Text.")
+	moved[0].DemoteHeadings = &demote
+	assert.NotEqual(t, key, cacheKey("doc.go", moved, opts))
+	opts.License = []byte("BSD")
+	assert.NotEqual(t, key, cacheKey("doc.go", docs("Text."), opts))
+}
@@ -0,0 +1,550 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+var goListCmd = []string{"list", "-f", "{{.Name}}"}
+
+// config holds the options for a single run of the tool.
+type config struct {
+	input           string
+	output          string
+	stdout          bool
+	stdin           bool
+	pkg             string
+	license         bool
+	licenseFile     string
+	badges          bool
+	repo            string
+	branch          string
+	anchors         string
+	reformat        bool
+	formatCode      bool
+	skipLangs       stringsFlag
+	langCaptions    bool
+	generatedHeader bool
+	demoteHeadings  render.Demotion
+	template        bool
+	version         string
+	toc             bool
+	synopsisFrom    string
+	synopsisLimit   int
+	includeSections stringsFlag
+	excludeSections stringsFlag
+
+	// set records the flags given explicitly on the command line, which take
+	// precedence over the project configuration file
+	set map[string]bool
+	// warnings is where problems that don't stop generation are reported
+	warnings io.Writer
+	// moreInputs are rendered after the input, when -input lists several
+	// files or a glob
+	moreInputs []string
+	// force makes gen render the input even if the cache says the output is
+	// up to date
+	force bool
+}
+
+// register binds the options of c to flags in fs.
+func (c *config) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.input, "input", "README.md", "Path to markdown file to parse. May be a comma separated list of files or globs, rendered in order")
+	fs.StringVar(&c.output, "output", "doc.go", "Path to write file to")
+	fs.BoolVar(&c.stdout, "stdout", false, "Write to STDOUT instead of a file")
+	fs.BoolVar(&c.stdin, "stdin", false, "Read from STDIN instead of a file")
+	fs.StringVar(&c.pkg, "pkg", "", "Package name. If empty, infer from directory of input")
+	fs.BoolVar(&c.license, "license", true, "Add license header from file")
+	fs.StringVar(&c.licenseFile, "licenseFile", "LICENSE.txt", "File to read license header from")
+	fs.BoolVar(&c.badges, "badges", false, "Enable output for badges (links with images)")
+	fs.StringVar(&c.repo, "repo", "", "Base URL of the repository, used to rewrite relative links to files")
+	fs.StringVar(&c.branch, "branch", "master", "Repository branch to point rewritten links at")
+	fs.StringVar(&c.anchors, "anchors", string(render.AnchorDrop), "How to render links to headings: drop, heading or godoc")
+	fs.BoolVar(&c.reformat, "reformat", false, "Rewrap the generated documentation with go/doc/comment")
+	fs.BoolVar(&c.formatCode, "gofmt-code", false, "Run gofmt on go code blocks")
+	fs.Var(&c.skipLangs, "skip-lang", "Leave out code blocks in this language, such as mermaid. May be repeated")
+	fs.BoolVar(&c.langCaptions, "lang-captions", false, "Put a Language: caption above code blocks that aren't go")
+	fs.BoolVar(&c.template, "template", false, "Expand the input as a text/template, with {{.ImportPath}}, {{.Package}}, {{.Version}} and {{include \"file\" \"region\"}}")
+	fs.StringVar(&c.version, "version", "", "Version for {{.Version}} in templates. If empty, use the front matter or git describe")
+	fs.Var(&c.demoteHeadings, "demote-headings", "Demote the headings of every input but the first by one level, or by N with -demote-headings=N")
+	fs.StringVar(&c.synopsisFrom, "synopsis-from", string(render.SynopsisHeading), "Where the synopsis comes from, unless the front matter sets it: heading, paragraph or front-matter")
+	fs.IntVar(&c.synopsisLimit, "synopsis-limit", render.DefaultSynopsisLimit, "Warn about synopses longer than this")
+	fs.BoolVar(&c.toc, "toc", false, "List the sections after the synopsis, or in place of a <!-- toc --> marker")
+	fs.BoolVar(&c.generatedHeader, "generated-header", false, "Start the output with a \"Code generated ... DO NOT EDIT.\" header")
+	fs.Var(&c.includeSections, "include-section", "Only keep sections with this heading (text, or /regexp/). May be repeated")
+	fs.Var(&c.excludeSections, "exclude-section", "Remove sections with this heading (text, or /regexp/). May be repeated")
+}
+
+// stringsFlag is a flag that may be repeated, collecting every value.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// newConfig parses the generation flags in args, returning nil if the command
+// should not go ahead, along with the exit code.
+func newConfig(fs *flag.FlagSet, args []string) (*config, int) {
+	c := &config{set: make(map[string]bool), warnings: fs.Output()}
+	c.register(fs)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, 0
+		}
+		return nil, 2
+	}
+	fs.Visit(func(f *flag.Flag) {
+		c.set[f.Name] = true
+	})
+	if err := c.setInputs(c.input, ""); err != nil {
+		fmt.Fprintf(fs.Output(), "invalid value %q for flag -input: %v\n", c.input, err)
+		return nil, 2
+	}
+	if err := c.checkChoices("flag -"); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return nil, 2
+	}
+	return c, 0
+}
+
+// choice is an option that takes one of a fixed set of values.
+type choice struct {
+	name    string
+	value   string
+	allowed []string
+}
+
+func (c *config) choices() []choice {
+	return []choice{
+		{"anchors", c.anchors, []string{string(render.AnchorDrop), string(render.AnchorHeading), string(render.AnchorGodoc)}},
+		{"synopsis-from", c.synopsisFrom, []string{string(render.SynopsisHeading), string(render.SynopsisParagraph), string(render.SynopsisFrontMatter)}},
+	}
+}
+
+// checkChoices returns an error for the first option set to a value it doesn't
+// take. Options are named with prefix in the error, such as "flag -".
+func (c *config) checkChoices(prefix string) error {
+	for _, ch := range c.choices() {
+		ok := false
+		for _, allowed := range ch.allowed {
+			ok = ok || ch.value == allowed
+		}
+		if !ok {
+			return fmt.Errorf("invalid value %q for %s%s: must be one of %s", ch.value, prefix, ch.name, strings.Join(ch.allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// setInputs sets the inputs from a comma separated list of files or globs,
+// relative to dir. Files are kept in the order given and the matches of each
+// glob are sorted.
+func (c *config) setInputs(list, dir string) error {
+	var inputs []string
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = filepath.Join(dir, strings.TrimSpace(pattern))
+		if !strings.ContainsAny(pattern, "*?[") {
+			inputs = append(inputs, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no files match %v", pattern)
+		}
+		inputs = append(inputs, matches...)
+	}
+	c.input, c.moreInputs = inputs[0], inputs[1:]
+	return nil
+}
+
+// generate renders the input to godoc and writes it out.
+func (c *config) generate(stdin io.Reader, stdout io.Writer) error {
+	if c.stdout {
+		output, err := c.render(stdin)
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(output)
+		return err
+	}
+	_, err := c.update(stdin)
+	return err
+}
+
+// load applies the project configuration, reads the inputs and splits off
+// their front matter. Only that of the first input is applied, other than
+// demote-headings, which each input may set for itself.
+func (c *config) load(stdin io.Reader) (render.FrontMatter, []render.Document, error) {
+	var fm render.FrontMatter
+	if err := c.applyConfig(); err != nil {
+		return fm, nil, fmt.Errorf("could not load config: %v", err)
+	}
+
+	r, err := c.reader(stdin)
+	if err != nil {
+		return fm, nil, err
+	}
+	input, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return fm, nil, fmt.Errorf("could not read input file: %v", err)
+	}
+
+	fm, input, err = render.SplitFrontMatter(input)
+	if err != nil {
+		return fm, nil, fmt.Errorf("could not parse front matter: %v", err)
+	}
+	c.applyFrontMatter(fm)
+
+	docs := []render.Document{{Name: c.inputName(), Markdown: input, DemoteHeadings: fm.DemoteHeadings}}
+	for _, file := range c.moreInputs {
+		input, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fm, nil, fmt.Errorf("could not read input file: %v", err)
+		}
+		more, input, err := render.SplitFrontMatter(input)
+		if err != nil {
+			return fm, nil, fmt.Errorf("could not parse front matter of %v: %v", file, err)
+		}
+		docs = append(docs, render.Document{
+			Name:           file,
+			Dir:            c.inputDir(file),
+			Markdown:       input,
+			DemoteHeadings: more.DemoteHeadings,
+		})
+	}
+	return fm, docs, nil
+}
+
+// inputDir returns the directory of file relative to that of the first
+// input, which relative links in file are resolved against.
+func (c *config) inputDir(file string) string {
+	rel, err := filepath.Rel(filepath.Dir(c.input), filepath.Dir(file))
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// inputName names the first input in messages.
+func (c *config) inputName() string {
+	if c.stdin {
+		return "<stdin>"
+	}
+	return c.input
+}
+
+// licenseHeader returns the contents of the license file, if there should be
+// a license header and the file exists.
+func (c *config) licenseHeader() ([]byte, error) {
+	if !c.license {
+		return nil, nil
+	}
+	if _, err := os.Stat(c.licenseFile); err != nil {
+		return nil, nil
+	}
+	return ioutil.ReadFile(c.licenseFile)
+}
+
+// render reads the input and renders it into the source of a doc.go file.
+func (c *config) render(stdin io.Reader) ([]byte, error) {
+	docs, opts, err := c.renderOptions(stdin)
+	if err != nil {
+		return nil, err
+	}
+	return render.GenerateDocuments(docs, opts)
+}
+
+// renderOptions reads the inputs, returning them without front matter along
+// with the options to render them with.
+func (c *config) renderOptions(stdin io.Reader) ([]render.Document, render.Options, error) {
+	var opts render.Options
+	fm, docs, err := c.load(stdin)
+	if err != nil {
+		return nil, opts, err
+	}
+	pkg, err := c.packageName()
+	if err != nil {
+		return nil, opts, err
+	}
+	if err := c.expand(docs, c.templateData(pkg, fm)); err != nil {
+		return nil, opts, err
+	}
+	filter, err := c.sectionFilter()
+	if err != nil {
+		return nil, opts, err
+	}
+	license, err := c.licenseHeader()
+	if err != nil {
+		return nil, opts, err
+	}
+	if c.template && license != nil {
+		if license, err = render.Expand(license, filepath.Dir(c.licenseFile), c.templateData(pkg, fm)); err != nil {
+			return nil, opts, fmt.Errorf("could not expand template in %v: %v", c.licenseFile, err)
+		}
+	}
+
+	return docs, render.Options{
+		Package:          pkg,
+		Badges:           c.badges,
+		Links:            c.links(),
+		Anchors:          render.AnchorStyle(c.anchors),
+		Title:            fm.Title,
+		Synopsis:         fm.Synopsis,
+		SynopsisFrom:     render.SynopsisSource(c.synopsisFrom),
+		SynopsisLimit:    c.synopsisLimit,
+		Sections:         filter,
+		License:          license,
+		DemoteHeadings:   c.demoteHeadings,
+		GeneratedFrom:    c.generatedFrom(),
+		Reformat:         c.reformat,
+		FormatCode:       c.formatCode,
+		SkipLanguages:    c.skipLangs,
+		LanguageCaptions: c.langCaptions,
+		TOC:              c.toc,
+		Warnf:            c.warnf,
+	}, nil
+}
+
+// generatedFrom names the inputs in the generated header, relative to the
+// output, or returns an empty string if there shouldn't be a header.
+func (c *config) generatedFrom() string {
+	if !c.generatedHeader {
+		return ""
+	}
+	var names []string
+	if c.stdin {
+		names = append(names, "standard input")
+	} else {
+		names = append(names, c.relToOutput(c.input))
+	}
+	for _, file := range c.moreInputs {
+		names = append(names, c.relToOutput(file))
+	}
+	return strings.Join(names, ", ")
+}
+
+// relToOutput returns file relative to the directory of the output.
+func (c *config) relToOutput(file string) string {
+	rel, err := filepath.Rel(filepath.Dir(c.outputPath()), file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// warnf reports a problem with the input that doesn't stop generation.
+func (c *config) warnf(format string, args ...interface{}) {
+	fmt.Fprintf(c.warnings, "md-to-godoc: warning: %s: %s\n", c.inputName(), fmt.Sprintf(format, args...))
+}
+
+// applyFrontMatter lets the front matter of the input override command line
+// options.
+func (c *config) applyFrontMatter(fm render.FrontMatter) {
+	if fm.Package != "" {
+		c.pkg = fm.Package
+	}
+	if fm.Badges != nil {
+		c.badges = *fm.Badges
+	}
+	if fm.ExcludeSections != nil {
+		c.excludeSections = fm.ExcludeSections
+	}
+}
+
+func (c *config) sectionFilter() (render.SectionFilter, error) {
+	var f render.SectionFilter
+	for _, p := range c.includeSections {
+		re, err := render.SectionPattern(p)
+		if err != nil {
+			return f, fmt.Errorf("invalid section pattern %q: %v", p, err)
+		}
+		f.Include = append(f.Include, re)
+	}
+	for _, p := range c.excludeSections {
+		re, err := render.SectionPattern(p)
+		if err != nil {
+			return f, fmt.Errorf("invalid section pattern %q: %v", p, err)
+		}
+		f.Exclude = append(f.Exclude, re)
+	}
+	return f, nil
+}
+
+func (c *config) reader(stdin io.Reader) (io.ReadCloser, error) {
+	if c.stdin {
+		return ioutil.NopCloser(stdin), nil
+	}
+
+	return os.Open(c.input)
+}
+
+// outputPath returns the path of the file to write to.
+func (c *config) outputPath() string {
+	// Assume they want doc.go to go into the same directory as the input file,
+	// Unless they manually set the output.
+	inBase := filepath.Dir(c.input)
+	if inBase != "." && c.output == "doc.go" {
+		return path.Join(inBase, c.output)
+	}
+	return c.output
+}
+
+func (c *config) packageName() (string, error) {
+	if c.pkg != "" {
+		return c.pkg, nil
+	}
+	dir := filepath.Dir(c.input)
+	if !filepath.IsAbs(dir) && dir != "." {
+		dir = "./" + dir
+	}
+	output, err := goList("", append(goListCmd, dir)...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// goList runs the go command with args in dir, returning its output.
+func goList(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			err = errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("unable to run %v: %v", cmd.Args, err)
+	}
+	return output, nil
+}
+
+// links describes the location of the input's package for link rewriting.
+func (c *config) links() render.Links {
+	l := render.Links{
+		RepoURL: c.repo,
+		Branch:  c.branch,
+	}
+	if dir, err := filepath.Abs(filepath.Dir(c.input)); err == nil {
+		l.ImportPath, l.Dir = importPath(dir)
+	}
+	return l
+}
+
+// importPath returns the import path of the package in dir, and the
+// directory relative to the root of its module or repository. The import path
+// is derived from the nearest go.mod, or failing that from the location of
+// dir in the GOPATH.
+func importPath(dir string) (importPath, rel string) {
+	if root, module := findModule(dir); root != "" {
+		if rel, err := filepath.Rel(root, dir); err == nil {
+			rel = filepath.ToSlash(rel)
+			return path.Join(module, rel), rel
+		}
+	}
+	return gopathImportPath(dir)
+}
+
+// gopathImportPath returns the import path of dir from its location below
+// the src directory of a GOPATH entry, like PROJECT_ROOT in the Makefile, and
+// the directory relative to the nearest git repository above it.
+func gopathImportPath(dir string) (importPath, rel string) {
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		gopath = build.Default.GOPATH
+	}
+	for _, root := range filepath.SplitList(gopath) {
+		src := filepath.Join(root, "src")
+		rel, err := filepath.Rel(src, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return filepath.ToSlash(rel), repoDir(src, dir)
+	}
+	return "", ""
+}
+
+// repoDir returns dir relative to the root of the git repository it's in,
+// looking no higher than src.
+func repoDir(src, dir string) string {
+	for d := dir; d != src && d != filepath.Dir(d); d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			rel, err := filepath.Rel(d, dir)
+			if err != nil {
+				return ""
+			}
+			return filepath.ToSlash(rel)
+		}
+	}
+	return ""
+}
+
+// findModule walks up from dir looking for a go.mod file, returning the
+// directory it was found in and the module path it declares.
+func findModule(dir string) (root, module string) {
+	for {
+		if module := readModulePath(filepath.Join(dir, "go.mod")); module != "" {
+			return dir, module
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+func readModulePath(gomod string) string {
+	fb, err := ioutil.ReadFile(gomod)
+	if err != nil {
+		return ""
+	}
+	bs := bufio.NewScanner(bytes.NewBuffer(fb))
+	for bs.Scan() {
+		fields := strings.Fields(bs.Text())
+		if len(fields) < 2 || fields[0] != "module" {
+			continue
+		}
+		module := fields[1]
+		if unquoted, err := strconv.Unquote(module); err == nil {
+			module = unquoted
+		}
+		return module
+	}
+	return ""
+}
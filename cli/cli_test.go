@@ -0,0 +1,373 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+// parseConfig returns the configuration for args, as gen would see it.
+func parseConfig(t *testing.T, args ...string) *config {
+	c, _ := newConfig(flag.NewFlagSet("test", flag.ContinueOnError), args)
+	require.NotNil(t, c)
+	return c
+}
+
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "md-to-godoc")
+	require.NoError(t, err)
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestRun_Stdio(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("# Title\n\nSome text.\n")
+
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-license=false"}, stdin, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "// Package foo is the Title.\n//\n// Some text.\npackage foo\n", stdout.String())
+}
+
+func TestRun_File(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	input := filepath.Join(dir, "README.md")
+	license := filepath.Join(dir, "LICENSE.txt")
+	require.NoError(t, ioutil.WriteFile(input, []byte("# Title\n\nSome text.\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(license, []byte("Copyright 2016\n\nAll rights reserved.\n"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-input", input, "-licenseFile", license, "-pkg", "foo"}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Empty(t, stdout.String())
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "doc.go"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(contents), "// Copyright 2016\n//\n// All rights reserved.\n\n// Package foo is the Title."))
+}
+
+func TestRun_Help(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, Run([]string{"-h"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "-input")
+}
+
+func TestRun_BadFlag(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, Run([]string{"-nope"}, nil, &stdout, &stderr))
+}
+
+func TestRun_BadInput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, Run([]string{"-input", "non-existent", "-pkg", "foo"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "non-existent")
+}
+
+func TestRun_BadAnchors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-anchors=godco"}, strings.NewReader("Text.\n"), &stdout, &stderr)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr.String(), `invalid value "godco" for flag -anchors: must be one of drop, heading, godoc`)
+}
+
+func TestRun_BadSynopsisFrom(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-synopsis-from", "frontmatter"}, strings.NewReader("Text.\n"), &stdout, &stderr)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr.String(), `invalid value "frontmatter" for flag -synopsis-from: must be one of heading, paragraph, front-matter`)
+}
+
+func TestRun_BadSectionPattern(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("Text.\n")
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-exclude-section", "/(/"}, stdin, &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "invalid section pattern")
+}
+
+func TestRun_FormatCodeWarning(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("Text.\n\n```go\nfunc (\n```\n")
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-gofmt-code"}, stdin, &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "//\tfunc (\n")
+	assert.Contains(t, stderr.String(), "md-to-godoc: warning: <stdin>: could not gofmt code block")
+}
+
+func TestRun_SynopsisWarning(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("# A rather long title\n\nConverts things.\n")
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-license=false", "-synopsis-limit", "20"}, stdin, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stderr.String(), `md-to-godoc: warning: <stdin>: synopsis "Package foo is the A rather long title." is 39 characters long, more than 20`)
+
+	stdout.Reset()
+	stderr.Reset()
+	stdin = strings.NewReader("# A rather long title\n\nConverts things.\n")
+	code = Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-license=false", "-synopsis-from", "paragraph"}, stdin, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "", stderr.String())
+	assert.Equal(t, "// Package foo converts things.\npackage foo\n", stdout.String())
+}
+
+func TestRun_TOC(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("# Title\n\nIntro.\n\n## Usage\n\nRun it.\n\n## Contributing\n\nPlease do.\n")
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-license=false", "-toc", "-exclude-section", "Contributing"}, stdin, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "// Package foo is the Title.\n//\n// Contents:\n// • Usage\n//\n// Intro.\n")
+}
+
+func TestRun_MultipleInputs(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	files := map[string]string{
+		"README.md":      "# Title\n\nIntro.\n",
+		"docs/USAGE.md":  "---\ntitle: Ignored\n---\n# Usage\n\nRun it.\n",
+		"docs/DESIGN.md": "# Design\n\nSimple.\n\n# Usage\n\nAgain.\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+
+	var stdout, stderr bytes.Buffer
+	inputs := filepath.Join(dir, "README.md") + "," + filepath.Join(dir, "docs", "*.md")
+	code := Run([]string{"-input", inputs, "-stdout", "-pkg", "foo", "-license=false", "-demote-headings"}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t,
+		"// Package foo is the Title.\n//\n// Intro.\n//\n"+
+			"// # Design\n//\n// Simple.\n//\n// # Usage\n//\n// Again.\n//\n"+
+			"// # Usage\n//\n// Run it.\n"+
+			"package foo\n",
+		stdout.String())
+	assert.Contains(t, stderr.String(), `heading "Usage" in `+filepath.Join(dir, "docs", "USAGE.md")+" is also in "+filepath.Join(dir, "docs", "DESIGN.md"))
+}
+
+func TestRun_MultipleInputsPerFile(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	files := map[string]string{
+		"README.md":     "# Title\n\nIntro.\n",
+		"docs/USAGE.md": "---\ndemote-headings: 2\n---\n# Usage\n\nSee [design](DESIGN.md) and [contributing](../CONTRIBUTING.md).\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+
+	var stdout, stderr bytes.Buffer
+	inputs := filepath.Join(dir, "README.md") + "," + filepath.Join(dir, "docs", "USAGE.md")
+	code := Run([]string{"-input", inputs, "-stdout", "-pkg", "foo", "-license=false", "-repo", "https://github.com/x/y"}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "design (https://github.com/x/y/blob/master/docs/DESIGN.md)")
+	assert.Contains(t, stdout.String(), "contributing (https://github.com/x/y/blob/master/CONTRIBUTING.md)")
+
+	fm, docs, err := parseConfig(t, "-input", inputs).load(nil)
+	require.NoError(t, err)
+	assert.Nil(t, fm.DemoteHeadings)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "docs", docs[1].Dir)
+	require.NotNil(t, docs[1].DemoteHeadings)
+	assert.Equal(t, render.Demotion(2), *docs[1].DemoteHeadings)
+}
+
+func TestSetInputs_NoMatch(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, Run([]string{"-input", "README.md,nothing/*.md"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "no files match nothing/*.md")
+}
+
+func TestReader_Stdin(t *testing.T) {
+	c := parseConfig(t, "-stdin")
+
+	r, err := c.reader(strings.NewReader("hello"))
+	require.NoError(t, err)
+	contents, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(contents))
+}
+
+func TestReader_BadFile(t *testing.T) {
+	c := parseConfig(t, "-input", "non-existent")
+
+	_, err := c.reader(nil)
+	assert.Error(t, err)
+}
+
+func TestReader_GoodFile(t *testing.T) {
+	c := parseConfig(t, "-input", "../README.md")
+
+	r, err := c.reader(nil)
+	require.NoError(t, err)
+	assert.NoError(t, r.Close())
+}
+
+func TestGenerate_Stdout(t *testing.T) {
+	var stdout bytes.Buffer
+	c := parseConfig(t, "-stdin", "-stdout", "-pkg", "foo")
+
+	require.NoError(t, c.generate(strings.NewReader("Text.\n"), &stdout))
+	assert.Contains(t, stdout.String(), "package foo\n")
+}
+
+func TestGenerate_CustomFile(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	out := filepath.Join(dir, "out.go")
+	c := parseConfig(t, "-stdin", "-output", out, "-pkg", "foo")
+
+	require.NoError(t, c.generate(strings.NewReader("Text.\n"), nil))
+	contents, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "package foo\n")
+}
+
+func TestOutputPath_NextToInput(t *testing.T) {
+	c := parseConfig(t, "-input", "render/README.md")
+	assert.Equal(t, "render/doc.go", c.outputPath())
+
+	c = parseConfig(t, "-input", "render/README.md", "-output", "out.go")
+	assert.Equal(t, "out.go", c.outputPath())
+}
+
+func TestPackageName_Overridden(t *testing.T) {
+	c := parseConfig(t, "-pkg", "seattle")
+
+	pkg, err := c.packageName()
+	require.NoError(t, err)
+	assert.Equal(t, "seattle", pkg)
+}
+
+func TestPackageName_Inferred(t *testing.T) {
+	c := parseConfig(t, "-input", "../README.md")
+
+	pkg, err := c.packageName()
+	require.NoError(t, err)
+	assert.Equal(t, "main", pkg)
+}
+
+func TestPackageName_BadDir(t *testing.T) {
+	c := parseConfig(t, "-input", "/foobar")
+
+	_, err := c.packageName()
+	assert.Error(t, err)
+}
+
+func TestPackageName_Relative(t *testing.T) {
+	c := parseConfig(t, "-input", "../render/README.md")
+
+	pkg, err := c.packageName()
+	require.NoError(t, err)
+	assert.Equal(t, "render", pkg)
+}
+
+func TestNewConfig_Help(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	c, code := newConfig(fs, []string{"-h"})
+	assert.Nil(t, c)
+	assert.Equal(t, 0, code)
+}
+
+func TestRender_MissingLicense(t *testing.T) {
+	c := parseConfig(t, "-stdin", "-pkg", "foo", "-licenseFile", "non-existent")
+
+	output, err := c.render(strings.NewReader("Text.\n"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(output), "// Package foo"))
+}
+
+func TestFindModule(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	gomod := "// comment\nmodule \"example.com/mod\"\n\ngo 1.12\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0644))
+	sub := filepath.Join(dir, "a", "b")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	root, module := findModule(sub)
+	assert.Equal(t, dir, root)
+	assert.Equal(t, "example.com/mod", module)
+}
+
+func TestLinks_NoModule(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	c := parseConfig(t, "-input", filepath.Join(dir, "README.md"), "-repo", "https://github.com/sectioneight/md-to-godoc")
+
+	l := c.links()
+	assert.Equal(t, "", l.ImportPath)
+	assert.Equal(t, "https://github.com/sectioneight/md-to-godoc", l.RepoURL)
+	assert.Equal(t, "master", l.Branch)
+}
+
+func TestImportPath_GOPATH(t *testing.T) {
+	gopath, cleanup := tempDir(t)
+	defer cleanup()
+	repo := filepath.Join(gopath, "src", "example.com", "repo")
+	pkg := filepath.Join(repo, "sub", "pkg")
+	require.NoError(t, os.MkdirAll(pkg, 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0755))
+
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	os.Setenv("GOPATH", gopath)
+
+	importPath, rel := importPath(pkg)
+	assert.Equal(t, "example.com/repo/sub/pkg", importPath)
+	assert.Equal(t, "sub/pkg", rel)
+
+	importPath, rel = gopathImportPath(filepath.Join(gopath, "src"))
+	assert.Equal(t, "", importPath)
+	assert.Equal(t, "", rel)
+}
+
+func TestImportPath_Module(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/mod\n"), 0644))
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(sub, 0755))
+
+	importPath, rel := importPath(sub)
+	assert.Equal(t, "example.com/mod/sub", importPath)
+	assert.Equal(t, "sub", rel)
+}
+
+func TestSectionFilter(t *testing.T) {
+	c := parseConfig(t, "-exclude-section", "Status", "-exclude-section", "/^Projects/")
+
+	f, err := c.sectionFilter()
+	require.NoError(t, err)
+	assert.Empty(t, f.Include)
+	require.Len(t, f.Exclude, 2)
+	assert.True(t, f.Exclude[0].MatchString("status"))
+	assert.True(t, f.Exclude[1].MatchString("Projects using md-to-godoc"))
+}
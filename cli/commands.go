@@ -0,0 +1,381 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cli implements the md-to-godoc command line tool, so that it can be
+// run from other binaries as well as its own.
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+// stdio holds the standard streams of a command.
+type stdio struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is a subcommand of md-to-godoc.
+type command struct {
+	name    string
+	summary string
+	help    string
+	run     func(cmd *command, args []string, std stdio) int
+}
+
+var commands []*command
+
+func init() {
+	// Registered here rather than in the declaration, as help refers back
+	// to commands
+	commands = []*command{
+		{
+			name:    "gen",
+			summary: "generate godoc from markdown (the default)",
+			help: "Gen renders the markdown input into godoc package documentation and\n" +
+				"writes it to the output, doc.go next to the input by default.",
+			run: runGen,
+		},
+		{
+			name:    "check",
+			summary: "check that generated godoc is up to date",
+			help: "Check renders the markdown input and compares it with the current output,\n" +
+				"exiting with a non-zero status if the output is missing or out of date.",
+			run: runCheck,
+		},
+		{
+			name:    "diff",
+			summary: "show what generating godoc would change",
+			help: "Diff renders the markdown input and prints a unified diff against the\n" +
+				"current output, without writing anything. It's the same as gen -diff.",
+			run: runDiff,
+		},
+		{
+			name:    "reverse",
+			summary: "convert godoc package documentation to markdown",
+			help: "Reverse reads the package documentation of a Go file, doc.go by default,\n" +
+				"and prints it as markdown.",
+			run: runReverse,
+		},
+		{
+			name:    "preview",
+			summary: "preview generated godoc without writing it",
+			help: "Preview renders the markdown input and prints the generated source,\n" +
+				"or the documentation as HTML or text. With -http, it serves the HTML and\n" +
+				"reloads it whenever the input changes.",
+			run: runPreview,
+		},
+		{
+			name:    "examples",
+			summary: "extract go code blocks into example tests",
+			help: "Examples writes the go code blocks of the markdown input to Example\n" +
+				"functions in example_readme_test.go next to the input, or to -output if\n" +
+				"it's given, so that go test compiles and runs them.",
+			run: runExamples,
+		},
+		{
+			name:    "init",
+			summary: "write a starter " + configFile,
+			help: "Init writes a starter " + configFile + " to the current directory,\n" +
+				"listing the defaults of every option.",
+			run: runInit,
+		},
+		{
+			name:    "help",
+			summary: "show help for a command",
+			help:    "Help prints help for the given command, or lists the commands.",
+			run:     runHelp,
+		},
+	}
+}
+
+// Run is the entry point of the tool. It runs the subcommand named by the
+// first argument, gen if there isn't one, and returns the exit code. Args
+// don't include the program name, so a binary that bundles md-to-godoc with
+// other tools can pass on the arguments after its own subcommand.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	std := stdio{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runGen(lookupCommand("gen"), args, std)
+	}
+	cmd := lookupCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "md-to-godoc: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	return cmd.run(cmd, args[1:], std)
+}
+
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: md-to-godoc [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "md-to-godoc help <command>" for more about a command.`)
+}
+
+// flagSet returns the flags of cmd, with its help text as usage.
+func (cmd *command) flagSet(stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("md-to-godoc "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: md-to-godoc %s [flags]\n\n%s\n\nFlags:\n", cmd.name, cmd.help)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// fail reports err and returns the exit code for it.
+func fail(std stdio, err error) int {
+	fmt.Fprintln(std.stderr, "md-to-godoc:", err)
+	return 1
+}
+
+func runGen(cmd *command, args []string, std stdio) int {
+	fs := cmd.flagSet(std.stderr)
+	showDiff := fs.Bool("diff", false, "Print a unified diff against the current output instead of writing it")
+	dryRun := fs.Bool("dry-run", false, "List whether the output would be created, modified or left unchanged, without writing it")
+	watch := fs.Bool("watch", false, "Keep running, regenerating the output whenever the input, license or config file changes")
+	recursive := fs.Bool("recursive", false, "Generate the output for every input with the same name in the directory of the input and below it")
+	jobs := fs.Int("j", runtime.NumCPU(), "Number of inputs to render at once with -recursive")
+	force := fs.Bool("force", false, "Render the input even if the cache says the output is up to date")
+	c, code := newConfig(fs, args)
+	if c == nil {
+		return code
+	}
+	c.force = *force
+
+	if *recursive {
+		if *watch {
+			return fail(std, errors.New("-recursive can't be used with -watch"))
+		}
+		mode := genWrite
+		switch {
+		case *showDiff:
+			mode = genDiff
+		case *dryRun:
+			mode = genDryRun
+		}
+		return runRecursive(c, mode, *jobs, std)
+	}
+
+	if *watch {
+		if c.stdin {
+			return fail(std, errors.New("-watch can't be used with -stdin"))
+		}
+		stop := make(chan struct{})
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+		go func() {
+			<-interrupt
+			close(stop)
+		}()
+		c.watch(stop, std.stdout, std.stderr)
+		return 0
+	}
+
+	if *showDiff || *dryRun {
+		p, err := c.preview(std.stdin)
+		if err != nil {
+			return fail(std, err)
+		}
+		if *dryRun {
+			fmt.Fprintf(std.stdout, "%-9s %s\n", p.status(), p.path)
+		}
+		if *showDiff {
+			writeDiff(std.stdout, p.path, p.current, p.output, isTerminal(std.stdout))
+		}
+		return 0
+	}
+
+	if err := c.generate(std.stdin, std.stdout); err != nil {
+		return fail(std, err)
+	}
+	return 0
+}
+
+// pending is output that has been rendered but not written yet.
+type pending struct {
+	path    string
+	current []byte // nil if the file doesn't exist
+	output  []byte
+}
+
+// preview renders the input to memory, along with the current output.
+func (c *config) preview(stdin io.Reader) (*pending, error) {
+	output, err := c.render(stdin)
+	if err != nil {
+		return nil, err
+	}
+	p := &pending{path: c.outputPath(), output: output}
+	p.current, err = ioutil.ReadFile(p.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return p, nil
+}
+
+// status describes what writing the output would do to the file.
+func (p *pending) status() string {
+	switch {
+	case p.current == nil:
+		return "create"
+	case bytes.Equal(p.current, p.output):
+		return "unchanged"
+	default:
+		return "modify"
+	}
+}
+
+// isTerminal returns true if w is a terminal, where output may be colored.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func runCheck(cmd *command, args []string, std stdio) int {
+	c, code := newConfig(cmd.flagSet(std.stderr), args)
+	if c == nil {
+		return code
+	}
+	p, err := c.preview(std.stdin)
+	if err != nil {
+		return fail(std, err)
+	}
+	switch p.status() {
+	case "create":
+		fmt.Fprintf(std.stderr, "%v is missing\n", p.path)
+		return 1
+	case "modify":
+		if _, edited := render.HandEdited(p.current); edited {
+			fmt.Fprintf(std.stderr, "md-to-godoc: warning: %v was edited by hand, but is generated from %v\n", p.path, c.input)
+		}
+		fmt.Fprintf(std.stderr, "%v is out of date, run md-to-godoc gen\n", p.path)
+		return 1
+	}
+	return 0
+}
+
+func runDiff(cmd *command, args []string, std stdio) int {
+	c, code := newConfig(cmd.flagSet(std.stderr), args)
+	if c == nil {
+		return code
+	}
+	p, err := c.preview(std.stdin)
+	if err != nil {
+		return fail(std, err)
+	}
+	writeDiff(std.stdout, p.path, p.current, p.output, isTerminal(std.stdout))
+	return 0
+}
+
+// starterConfig is written by init.
+const starterConfig = `# Configuration for md-to-godoc, used for every README below this directory.
+# Command line flags take precedence over anything set here.
+
+input: README.md
+output: doc.go
+license: true
+licenseFile: LICENSE.txt
+badges: false
+anchors: drop
+reformat: false
+gofmt-code: false
+# skip-languages: [mermaid]
+lang-captions: false
+generated-header: false
+demote-headings: 0
+template: false
+# version: v1.0.0
+toc: false
+synopsis-from: heading
+synopsis-limit: 100
+# repo: https://github.com/user/project
+branch: master
+# include-sections: []
+exclude-sections: []
+
+# Overrides apply to inputs in matching directories, relative to this file.
+# overrides:
+#   - path: internal/...
+#     license: false
+`
+
+func runInit(cmd *command, args []string, std stdio) int {
+	fs := cmd.flagSet(std.stderr)
+	force := fs.Bool("force", false, "Overwrite an existing "+configFile)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	if _, err := os.Stat(configFile); err == nil && !*force {
+		return fail(std, fmt.Errorf("%v already exists, use -force to overwrite it", configFile))
+	}
+	if err := ioutil.WriteFile(configFile, []byte(starterConfig), 0644); err != nil {
+		return fail(std, err)
+	}
+	fmt.Fprintln(std.stdout, "Wrote", configFile)
+	return 0
+}
+
+func runHelp(cmd *command, args []string, std stdio) int {
+	if len(args) == 0 {
+		usage(std.stdout)
+		return 0
+	}
+	if strings.HasPrefix(args[0], "-") {
+		cmd.flagSet(std.stdout).Usage()
+		return 0
+	}
+	target := lookupCommand(args[0])
+	if target == nil {
+		fmt.Fprintf(std.stderr, "md-to-godoc: unknown command %q\n", args[0])
+		return 2
+	}
+	// Every command prints its help when run with -h
+	return target.run(target, []string{"-h"}, stdio{stdin: std.stdin, stdout: std.stdout, stderr: std.stdout})
+}
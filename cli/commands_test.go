@@ -0,0 +1,189 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPackage writes a README to a temporary directory, returning the
+// arguments to render it.
+func testPackage(t *testing.T) (dir string, args []string, cleanup func()) {
+	dir, cleanup = tempDir(t)
+	input := filepath.Join(dir, "README.md")
+	require.NoError(t, ioutil.WriteFile(input, []byte("# Title\n\nSome text.\n"), 0644))
+	return dir, []string{"-input", input, "-pkg", "foo", "-license=false"}, cleanup
+}
+
+func TestRun_Commands(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, Run([]string{"help"}, nil, &stdout, &stderr))
+	for _, cmd := range commands {
+		assert.Contains(t, stdout.String(), "  "+cmd.name+" ")
+	}
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, Run([]string{"bogus"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `unknown command "bogus"`)
+}
+
+func TestRun_HelpCommand(t *testing.T) {
+	for _, cmd := range commands {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 0, Run([]string{"help", cmd.name}, nil, &stdout, &stderr), cmd.name)
+		assert.Contains(t, stdout.String(), "Usage: md-to-godoc "+cmd.name, cmd.name)
+		assert.Contains(t, stdout.String(), cmd.help, cmd.name)
+	}
+}
+
+func TestRun_GenCommand(t *testing.T) {
+	dir, args, cleanup := testPackage(t)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, Run(append([]string{"gen"}, args...), nil, &stdout, &stderr), stderr.String())
+	_, err := os.Stat(filepath.Join(dir, "doc.go"))
+	assert.NoError(t, err)
+}
+
+func TestRun_CheckCommand(t *testing.T) {
+	_, args, cleanup := testPackage(t)
+	defer cleanup()
+	args = append([]string{"check"}, args...)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, Run(args, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "is missing")
+
+	require.Equal(t, 0, Run(append([]string{"gen"}, args[1:]...), nil, &stdout, &stderr))
+	stderr.Reset()
+	assert.Equal(t, 0, Run(args, nil, &stdout, &stderr), stderr.String())
+
+	args = append(args, "-pkg", "bar")
+	assert.Equal(t, 1, Run(args, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "is out of date")
+}
+
+func TestRun_CheckHandEdited(t *testing.T) {
+	dir, args, cleanup := testPackage(t)
+	defer cleanup()
+	args = append(args, "-generated-header")
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, Run(args, nil, &stdout, &stderr), stderr.String())
+	out := filepath.Join(dir, "doc.go")
+	contents, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(contents), "// Code generated by md-to-godoc from README.md; DO NOT EDIT.\n"))
+
+	edited := strings.Replace(string(contents), "Some text.", "Some other text.", 1)
+	require.NoError(t, ioutil.WriteFile(out, []byte(edited), 0644))
+	assert.Equal(t, 1, Run(append([]string{"check"}, args...), nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "doc.go was edited by hand, but is generated from")
+	assert.Contains(t, stderr.String(), "is out of date")
+}
+
+func TestRun_DiffCommand(t *testing.T) {
+	dir, args, cleanup := testPackage(t)
+	defer cleanup()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "doc.go"), []byte("// Package foo is the Title.\n"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, Run(append([]string{"diff"}, args...), nil, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "+// Some text.\n")
+
+	// Nothing was written
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "doc.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "// Package foo is the Title.\n", string(contents))
+}
+
+func TestRun_DiffFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, Run([]string{"help", "diff"}, nil, &stdout, &stderr))
+	for _, flag := range []string{"-watch", "-recursive", "-dry-run", "-diff"} {
+		assert.NotContains(t, stdout.String(), flag+" ")
+	}
+
+	for _, flag := range []string{"-watch", "-recursive", "-dry-run"} {
+		stderr.Reset()
+		assert.Equal(t, 2, Run([]string{"diff", flag}, nil, &stdout, &stderr), flag)
+		assert.Contains(t, stderr.String(), "flag provided but not defined", flag)
+	}
+}
+
+func TestRun_GenDryRun(t *testing.T) {
+	dir, args, cleanup := testPackage(t)
+	defer cleanup()
+	out := filepath.Join(dir, "doc.go")
+	args = append([]string{"gen", "-dry-run"}, args...)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, Run(args, nil, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "create    "+out+"\n", stdout.String())
+	_, err := os.Stat(out)
+	assert.True(t, os.IsNotExist(err), "dry run shouldn't write anything")
+
+	require.Equal(t, 0, Run(append([]string{"gen"}, args[2:]...), nil, &stdout, &stderr))
+	stdout.Reset()
+	require.Equal(t, 0, Run(args, nil, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "unchanged "+out+"\n", stdout.String())
+
+	stdout.Reset()
+	require.Equal(t, 0, Run(append(args, "-pkg", "bar", "-diff"), nil, &stdout, &stderr), stderr.String())
+	assert.True(t, strings.HasPrefix(stdout.String(), "modify    "+out+"\n--- a/"), stdout.String())
+	assert.Contains(t, stdout.String(), "+package bar\n")
+}
+
+func TestRun_PreviewCommand(t *testing.T) {
+	dir, args, cleanup := testPackage(t)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, Run(append([]string{"preview"}, args...), nil, &stdout, &stderr), stderr.String())
+	assert.True(t, strings.HasPrefix(stdout.String(), "// Package foo is the Title."))
+
+	_, err := os.Stat(filepath.Join(dir, "doc.go"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRun_InitCommand(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, Run([]string{"init"}, nil, &stdout, &stderr), stderr.String())
+	_, err = readConfig(configFile)
+	assert.NoError(t, err, "starter config should be valid")
+
+	assert.Equal(t, 1, Run([]string{"init"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "already exists")
+	assert.Equal(t, 0, Run([]string{"init", "-force"}, nil, &stdout, &stderr))
+}
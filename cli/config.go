@@ -0,0 +1,282 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+// configFile is the name of the project configuration file, looked up from
+// the directory of the input up to the module root.
+const configFile = ".md-to-godoc.yaml"
+
+// projectConfig is the contents of a project configuration file: defaults for
+// every input, and overrides for inputs below specific paths.
+type projectConfig struct {
+	options   `yaml:",inline"`
+	Overrides []override `yaml:"overrides"`
+}
+
+// override applies options to inputs whose directory, relative to the
+// configuration file, matches Path. Path is a glob, or a directory followed by
+// /... to match it and everything below it.
+type override struct {
+	Path    string `yaml:"path"`
+	options `yaml:",inline"`
+}
+
+// options mirror the command line flags. Unset options are left alone.
+type options struct {
+	Input           *string          `yaml:"input"`
+	Output          *string          `yaml:"output"`
+	Package         *string          `yaml:"pkg"`
+	License         *bool            `yaml:"license"`
+	LicenseFile     *string          `yaml:"licenseFile"`
+	Badges          *bool            `yaml:"badges"`
+	IncludeSections []string         `yaml:"include-sections"`
+	ExcludeSections []string         `yaml:"exclude-sections"`
+	Anchors         *string          `yaml:"anchors"`
+	Reformat        *bool            `yaml:"reformat"`
+	FormatCode      *bool            `yaml:"gofmt-code"`
+	SkipLanguages   []string         `yaml:"skip-languages"`
+	LangCaptions    *bool            `yaml:"lang-captions"`
+	GeneratedHeader *bool            `yaml:"generated-header"`
+	DemoteHeadings  *render.Demotion `yaml:"demote-headings"`
+	Template        *bool            `yaml:"template"`
+	Version         *string          `yaml:"version"`
+	TOC             *bool            `yaml:"toc"`
+	SynopsisFrom    *string          `yaml:"synopsis-from"`
+	SynopsisLimit   *int             `yaml:"synopsis-limit"`
+	Repo            *string          `yaml:"repo"`
+	Branch          *string          `yaml:"branch"`
+}
+
+// findConfig walks up from dir looking for a configuration file, stopping at
+// the module root. It returns an empty string if there isn't one.
+func findConfig(dir string) string {
+	for {
+		candidate := filepath.Join(dir, configFile)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func readConfig(file string) (*projectConfig, error) {
+	fb, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var pc projectConfig
+	if err := yaml.UnmarshalStrict(fb, &pc); err != nil {
+		return nil, fmt.Errorf("invalid config %v: %v", file, err)
+	}
+	return &pc, nil
+}
+
+// forDir returns the options for an input in dir, relative to the directory
+// of the configuration file, with matching overrides applied in order.
+func (pc *projectConfig) forDir(dir string) options {
+	opts := pc.options
+	for _, o := range pc.Overrides {
+		if o.matches(dir) {
+			opts = opts.merge(o.options)
+		}
+	}
+	return opts
+}
+
+func (o override) matches(dir string) bool {
+	if strings.HasSuffix(o.Path, "/...") {
+		prefix := path.Clean(strings.TrimSuffix(o.Path, "/..."))
+		return prefix == "." || dir == prefix || strings.HasPrefix(dir, prefix+"/")
+	}
+	ok, _ := path.Match(path.Clean(o.Path), dir)
+	return ok
+}
+
+// merge returns o with every option that is set in other replaced.
+func (o options) merge(other options) options {
+	if other.Input != nil {
+		o.Input = other.Input
+	}
+	if other.Output != nil {
+		o.Output = other.Output
+	}
+	if other.Package != nil {
+		o.Package = other.Package
+	}
+	if other.License != nil {
+		o.License = other.License
+	}
+	if other.LicenseFile != nil {
+		o.LicenseFile = other.LicenseFile
+	}
+	if other.Badges != nil {
+		o.Badges = other.Badges
+	}
+	if other.IncludeSections != nil {
+		o.IncludeSections = other.IncludeSections
+	}
+	if other.ExcludeSections != nil {
+		o.ExcludeSections = other.ExcludeSections
+	}
+	if other.Anchors != nil {
+		o.Anchors = other.Anchors
+	}
+	if other.Reformat != nil {
+		o.Reformat = other.Reformat
+	}
+	if other.FormatCode != nil {
+		o.FormatCode = other.FormatCode
+	}
+	if other.SkipLanguages != nil {
+		o.SkipLanguages = other.SkipLanguages
+	}
+	if other.LangCaptions != nil {
+		o.LangCaptions = other.LangCaptions
+	}
+	if other.GeneratedHeader != nil {
+		o.GeneratedHeader = other.GeneratedHeader
+	}
+	if other.DemoteHeadings != nil {
+		o.DemoteHeadings = other.DemoteHeadings
+	}
+	if other.Template != nil {
+		o.Template = other.Template
+	}
+	if other.Version != nil {
+		o.Version = other.Version
+	}
+	if other.TOC != nil {
+		o.TOC = other.TOC
+	}
+	if other.SynopsisFrom != nil {
+		o.SynopsisFrom = other.SynopsisFrom
+	}
+	if other.SynopsisLimit != nil {
+		o.SynopsisLimit = other.SynopsisLimit
+	}
+	if other.Repo != nil {
+		o.Repo = other.Repo
+	}
+	if other.Branch != nil {
+		o.Branch = other.Branch
+	}
+	return o
+}
+
+// applyConfig looks up the configuration file for the input and uses it for
+// every option that wasn't set on the command line.
+func (c *config) applyConfig() error {
+	dir, err := filepath.Abs(filepath.Dir(c.input))
+	if err != nil {
+		return err
+	}
+	file := findConfig(dir)
+	if file == "" {
+		return nil
+	}
+	pc, err := readConfig(file)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(filepath.Dir(file), dir)
+	if err != nil {
+		return err
+	}
+	opts := pc.forDir(filepath.ToSlash(rel))
+
+	// Input and output are names within the directory of the input, while
+	// the license file is relative to the configuration file.
+	inDir := filepath.Dir(c.input)
+	if opts.Input != nil && !c.set["input"] && !c.set["stdin"] {
+		if err := c.setInputs(*opts.Input, inDir); err != nil {
+			return err
+		}
+	}
+	if opts.Output != nil && !c.set["output"] && !c.set["stdout"] {
+		c.output = filepath.Join(inDir, *opts.Output)
+	}
+	if opts.LicenseFile != nil && !c.set["licenseFile"] {
+		c.licenseFile = *opts.LicenseFile
+		if !filepath.IsAbs(c.licenseFile) {
+			c.licenseFile = filepath.Join(filepath.Dir(file), c.licenseFile)
+		}
+	}
+	c.setString("pkg", &c.pkg, opts.Package)
+	c.setBool("license", &c.license, opts.License)
+	c.setBool("badges", &c.badges, opts.Badges)
+	c.setString("anchors", &c.anchors, opts.Anchors)
+	c.setBool("reformat", &c.reformat, opts.Reformat)
+	c.setBool("gofmt-code", &c.formatCode, opts.FormatCode)
+	c.setBool("lang-captions", &c.langCaptions, opts.LangCaptions)
+	c.setBool("generated-header", &c.generatedHeader, opts.GeneratedHeader)
+	if opts.DemoteHeadings != nil && !c.set["demote-headings"] {
+		c.demoteHeadings = *opts.DemoteHeadings
+	}
+	c.setBool("template", &c.template, opts.Template)
+	c.setString("version", &c.version, opts.Version)
+	c.setBool("toc", &c.toc, opts.TOC)
+	c.setString("synopsis-from", &c.synopsisFrom, opts.SynopsisFrom)
+	c.setInt("synopsis-limit", &c.synopsisLimit, opts.SynopsisLimit)
+	c.setString("repo", &c.repo, opts.Repo)
+	c.setString("branch", &c.branch, opts.Branch)
+	if opts.SkipLanguages != nil && !c.set["skip-lang"] {
+		c.skipLangs = opts.SkipLanguages
+	}
+	if opts.IncludeSections != nil && !c.set["include-section"] {
+		c.includeSections = opts.IncludeSections
+	}
+	if opts.ExcludeSections != nil && !c.set["exclude-section"] {
+		c.excludeSections = opts.ExcludeSections
+	}
+	return c.checkChoices("")
+}
+
+func (c *config) setString(name string, target, val *string) {
+	if val != nil && !c.set[name] {
+		*target = *val
+	}
+}
+
+func (c *config) setBool(name string, target, val *bool) {
+	if val != nil && !c.set[name] {
+		*target = *val
+	}
+}
+
+func (c *config) setInt(name string, target, val *int) {
+	if val != nil && !c.set[name] {
+		*target = *val
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+const testConfig = `
+badges: true
+licenseFile: HEADER.txt
+exclude-sections: [Contributing]
+overrides:
+  - path: internal/...
+    badges: false
+    anchors: godoc
+  - path: cmd/*
+    output: zz_doc.go
+`
+
+func TestFindConfig(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	sub := filepath.Join(dir, "a", "b")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	assert.Equal(t, "", findConfig(sub))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, configFile), nil, 0644))
+	assert.Equal(t, filepath.Join(dir, configFile), findConfig(sub))
+
+	// Lookup stops at the module root
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "go.mod"), nil, 0644))
+	assert.Equal(t, "", findConfig(sub))
+}
+
+func TestReadConfig(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	file := filepath.Join(dir, configFile)
+	require.NoError(t, ioutil.WriteFile(file, []byte(testConfig), 0644))
+	c, err := readConfig(file)
+	require.NoError(t, err)
+
+	opts := c.forDir(".")
+	require.NotNil(t, opts.Badges)
+	assert.True(t, *opts.Badges)
+	assert.Nil(t, opts.Anchors)
+	assert.Equal(t, []string{"Contributing"}, opts.ExcludeSections)
+
+	opts = c.forDir("internal/thing")
+	require.NotNil(t, opts.Badges)
+	assert.False(t, *opts.Badges)
+	require.NotNil(t, opts.Anchors)
+	assert.Equal(t, "godoc", *opts.Anchors)
+	assert.Equal(t, "HEADER.txt", *opts.LicenseFile)
+
+	opts = c.forDir("cmd/tool")
+	require.NotNil(t, opts.Output)
+	assert.Equal(t, "zz_doc.go", *opts.Output)
+
+	assert.Nil(t, c.forDir("cmd/tool/sub").Output)
+}
+
+func TestReadConfig_Invalid(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	file := filepath.Join(dir, configFile)
+	require.NoError(t, ioutil.WriteFile(file, []byte("unknown: true\n"), 0644))
+	_, err := readConfig(file)
+	assert.Error(t, err)
+}
+
+func TestApplyConfig(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, configFile), []byte(testConfig), 0644))
+	c := parseConfig(t, "-input", filepath.Join(dir, "README.md"))
+
+	require.NoError(t, c.applyConfig())
+	assert.True(t, c.badges)
+	assert.Equal(t, filepath.Join(dir, "HEADER.txt"), c.licenseFile)
+	assert.Equal(t, []string{"Contributing"}, []string(c.excludeSections))
+}
+
+func TestApplyConfig_InvalidChoice(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, configFile), []byte("anchors: godco\n"), 0644))
+	c := parseConfig(t, "-input", filepath.Join(dir, "README.md"))
+	assert.EqualError(t, c.applyConfig(), `invalid value "godco" for anchors: must be one of drop, heading, godoc`)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, configFile), []byte("synopsis-from: title\n"), 0644))
+	c = parseConfig(t, "-input", filepath.Join(dir, "README.md"))
+	assert.EqualError(t, c.applyConfig(), `invalid value "title" for synopsis-from: must be one of heading, paragraph, front-matter`)
+}
+
+func TestApplyConfig_DemoteHeadings(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	input := filepath.Join(dir, "README.md")
+
+	for config, want := range map[string]render.Demotion{"demote-headings: true\n": 1, "demote-headings: 2\n": 2} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, configFile), []byte(config), 0644))
+		c := parseConfig(t, "-input", input)
+		require.NoError(t, c.applyConfig())
+		assert.Equal(t, want, c.demoteHeadings, config)
+	}
+
+	// Flags take a number too, and win over the file
+	for _, tt := range []struct {
+		flag string
+		want render.Demotion
+	}{{"-demote-headings", 1}, {"-demote-headings=3", 3}, {"-demote-headings=false", 0}} {
+		c := parseConfig(t, "-input", input, tt.flag)
+		require.NoError(t, c.applyConfig())
+		assert.Equal(t, tt.want, c.demoteHeadings, tt.flag)
+	}
+}
+
+func TestApplyConfig_FlagsTakePrecedence(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, configFile), []byte(testConfig), 0644))
+	c := parseConfig(t, "-input", filepath.Join(dir, "README.md"), "-badges=false", "-exclude-section", "Usage")
+
+	require.NoError(t, c.applyConfig())
+	assert.False(t, c.badges)
+	assert.Equal(t, []string{"Usage"}, []string(c.excludeSections))
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// edit is a single line of a diff: unchanged (' '), removed ('-') or added
+// ('+').
+type edit struct {
+	op   byte
+	line []byte
+}
+
+// ANSI escape sequences for colored diffs.
+const (
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+// differ writes unified diffs, optionally colored like git does.
+type differ struct {
+	w     io.Writer
+	color bool
+}
+
+// line writes a line of the diff in the given color.
+func (d differ) line(color, format string, args ...interface{}) {
+	if d.color {
+		format = color + format + colorReset
+	}
+	fmt.Fprintf(d.w, format+"\n", args...)
+}
+
+// writeDiff writes a unified diff between the old and new contents of a file
+// to w, colored if color is set. Nothing is written if they are the same.
+func writeDiff(w io.Writer, name string, old, new []byte, color bool) {
+	if bytes.Equal(old, new) {
+		return
+	}
+	edits := diffLines(splitLines(old), splitLines(new))
+
+	d := differ{w: w, color: color}
+	d.line(colorBold, "--- a/%s", name)
+	d.line(colorBold, "+++ b/%s", name)
+	for start := 0; start < len(edits); {
+		// Find the next change, and the end of the hunk around it
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		hunkStart := max(first-diffContext, start)
+		end, unchanged := first, 0
+		for end < len(edits) && unchanged <= 2*diffContext {
+			if edits[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			end++
+		}
+		hunkEnd := min(end-unchanged+diffContext, len(edits))
+		d.hunk(edits, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+}
+
+func (d differ) hunk(edits []edit, start, end int) {
+	// Line numbers are those of the first line of the hunk in each file
+	oldLine, newLine := 1, 1
+	for _, e := range edits[:start] {
+		if e.op != '+' {
+			oldLine++
+		}
+		if e.op != '-' {
+			newLine++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, e := range edits[start:end] {
+		if e.op != '+' {
+			oldCount++
+		}
+		if e.op != '-' {
+			newCount++
+		}
+	}
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	d.line(colorCyan, "@@ -%d,%d +%d,%d @@", oldLine, oldCount, newLine, newCount)
+	for _, e := range edits[start:end] {
+		switch e.op {
+		case '-':
+			d.line(colorRed, "-%s", e.line)
+		case '+':
+			d.line(colorGreen, "+%s", e.line)
+		default:
+			fmt.Fprintf(d.w, " %s\n", e.line)
+		}
+	}
+}
+
+// diffLines returns the edits turning a into b, based on their longest common
+// subsequence.
+func diffLines(a, b [][]byte) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if bytes.Equal(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case bytes.Equal(a[i], b[j]):
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+	return edits
+}
+
+func splitLines(b []byte) [][]byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n"))
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func numberedLines(n int) []string {
+	var lines []string
+	for i := 1; i <= n; i++ {
+		lines = append(lines, strings.Repeat("x", i))
+	}
+	return lines
+}
+
+func TestWriteDiff_Same(t *testing.T) {
+	var buf bytes.Buffer
+	writeDiff(&buf, "doc.go", []byte("a\nb\n"), []byte("a\nb\n"), false)
+	assert.Empty(t, buf.String())
+}
+
+func TestWriteDiff_NewFile(t *testing.T) {
+	var buf bytes.Buffer
+	writeDiff(&buf, "doc.go", nil, []byte("a\nb\n"), false)
+	assert.Equal(t, "--- a/doc.go\n+++ b/doc.go\n@@ -0,0 +1,2 @@\n+a\n+b\n", buf.String())
+}
+
+func TestWriteDiff_Hunks(t *testing.T) {
+	old := numberedLines(20)
+	new := append([]string(nil), old...)
+	new[1] = "changed"
+	new = append(new[:15], new[16:]...)
+
+	var buf bytes.Buffer
+	writeDiff(&buf, "doc.go", []byte(strings.Join(old, "\n")+"\n"), []byte(strings.Join(new, "\n")+"\n"), false)
+
+	assert.Equal(t, `--- a/doc.go
++++ b/doc.go
+@@ -1,5 +1,5 @@
+ x
+-xx
++changed
+ xxx
+ xxxx
+ xxxxx
+@@ -13,7 +13,6 @@
+ xxxxxxxxxxxxx
+ xxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxx
+-xxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxxx
+`, buf.String())
+}
+
+func TestWriteDiff_Color(t *testing.T) {
+	var buf bytes.Buffer
+	writeDiff(&buf, "doc.go", []byte("a\nb\n"), []byte("a\nc\n"), true)
+	assert.Equal(t,
+		"\x1b[1m--- a/doc.go\x1b[0m\n\x1b[1m+++ b/doc.go\x1b[0m\n"+
+			"\x1b[36m@@ -1,2 +1,2 @@\x1b[0m\n a\n\x1b[31m-b\x1b[0m\n\x1b[32m+c\x1b[0m\n",
+		buf.String(),
+	)
+}
+
+func TestDiffLines(t *testing.T) {
+	edits := diffLines(splitLines([]byte("a\nb\nc\n")), splitLines([]byte("a\nc\nd\n")))
+
+	var ops []string
+	for _, e := range edits {
+		ops = append(ops, string(e.op)+string(e.line))
+	}
+	assert.Equal(t, []string{" a", "-b", " c", "+d"}, ops)
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+// examplesFile is where examples writes to, next to the input.
+const examplesFile = "example_readme_test.go"
+
+func runExamples(cmd *command, args []string, std stdio) int {
+	c, code := newConfig(cmd.flagSet(std.stderr), args)
+	if c == nil {
+		return code
+	}
+	output, err := c.examples(std.stdin)
+	if err != nil {
+		return fail(std, err)
+	}
+	if output == nil {
+		c.warnf("no Go code blocks to turn into examples")
+		return 0
+	}
+	if c.stdout {
+		std.stdout.Write(output)
+		return 0
+	}
+	if err := writeFile(c.examplesPath(), output); err != nil {
+		return fail(std, err)
+	}
+	return 0
+}
+
+// examples reads the input and renders its Go code blocks into the source of
+// an example test file.
+func (c *config) examples(stdin io.Reader) ([]byte, error) {
+	fm, docs, err := c.load(stdin)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := c.packageName()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.expand(docs, c.templateData(pkg, fm)); err != nil {
+		return nil, err
+	}
+	var input []byte
+	for _, doc := range docs {
+		input = append(append(input, doc.Markdown...), '\n')
+	}
+	license, err := c.licenseHeader()
+	if err != nil {
+		return nil, err
+	}
+	return render.Examples(input, render.ExampleOptions{
+		Package:    pkg,
+		ImportPath: c.links().ImportPath,
+		License:    license,
+	})
+}
+
+// examplesPath returns the path of the example test file, which is only
+// given by -output if it was set on the command line.
+func (c *config) examplesPath() string {
+	if c.set["output"] {
+		return c.output
+	}
+	return filepath.Join(filepath.Dir(c.input), examplesFile)
+}
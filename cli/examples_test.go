@@ -0,0 +1,67 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunExamples(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	input := filepath.Join(dir, "README.md")
+	md := "# Title\n\n```go example=Hello\nprintln(\"hello\")\n```\n"
+	require.NoError(t, ioutil.WriteFile(input, []byte(md), 0644))
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"examples", "-input", input, "-pkg", "foo", "-license=false"}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "example_readme_test.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package foo_test\n\nfunc ExampleHello() {\n\tprintln(\"hello\")\n}\n", string(contents))
+}
+
+func TestRunExamples_None(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	input := filepath.Join(dir, "README.md")
+	require.NoError(t, ioutil.WriteFile(input, []byte("# Title\n\n```sh\nls\n```\n"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"examples", "-input", input, "-pkg", "foo", "-license=false"}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stderr.String(), "no Go code blocks to turn into examples")
+
+	_, err := os.Stat(filepath.Join(dir, "example_readme_test.go"))
+	assert.True(t, os.IsNotExist(err), "no file should be written")
+}
+
+func TestExamplesPath(t *testing.T) {
+	c := parseConfig(t, "-input", "render/README.md")
+	assert.Equal(t, "render/example_readme_test.go", c.examplesPath())
+
+	c = parseConfig(t, "-input", "render/README.md", "-output", "out_test.go")
+	assert.Equal(t, "out_test.go", c.examplesPath())
+}
@@ -0,0 +1,243 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// reloadInterval is how often a served preview checks the input for changes.
+const reloadInterval = 500 * time.Millisecond
+
+func runPreview(cmd *command, args []string, std stdio) int {
+	fs := cmd.flagSet(std.stderr)
+	asHTML := fs.Bool("html", false, "Print the documentation as HTML, the way pkg.go.dev shows it")
+	asText := fs.Bool("text", false, "Print the documentation as text, the way go doc shows it")
+	addr := fs.String("http", "", "Serve the HTML preview on this address, such as localhost:6060, reloading it when the input changes")
+	c, code := newConfig(fs, args)
+	if c == nil {
+		return code
+	}
+
+	if *addr != "" {
+		if c.stdin {
+			return fail(std, errors.New("-http can't be used with -stdin"))
+		}
+		fmt.Fprintf(std.stderr, "Serving a preview of %v on http://%v/\n", c.input, *addr)
+		return fail(std, http.ListenAndServe(*addr, previewHandler(c)))
+	}
+
+	output, err := c.render(std.stdin)
+	if err != nil {
+		return fail(std, err)
+	}
+	if *asText {
+		if err := writeText(std.stdout, output, c.links().ImportPath, textWidth(std.stdout)); err != nil {
+			return fail(std, err)
+		}
+		return 0
+	}
+	if *asHTML {
+		if err := writeHTML(std.stdout, output, c.links().ImportPath, false); err != nil {
+			return fail(std, err)
+		}
+		return 0
+	}
+	std.stdout.Write(output)
+	return 0
+}
+
+// previewPage is what the preview template is executed with.
+type previewPage struct {
+	Package    string
+	ImportPath string
+	Doc        template.HTML
+	Reload     bool
+}
+
+// previewTemplate mimics the layout of a package page on pkg.go.dev.
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Package}} package{{with .ImportPath}} - {{.}}{{end}}</title>
+<style>
+body { margin: 0; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; color: #202224; line-height: 1.5; }
+header { background: #f8f8f8; border-bottom: 1px solid #dadce0; padding: 1.5rem 2rem; }
+header h1 { margin: 0; font-size: 2rem; font-weight: normal; }
+header .import { color: #5f6368; font-size: 0.875rem; }
+main { max-width: 60rem; padding: 1rem 2rem; }
+h2 { font-size: 1.375rem; border-bottom: 1px solid #dadce0; padding-bottom: 0.25rem; }
+h3 { font-size: 1.125rem; }
+pre { background: #f8f8f8; border: 1px solid #dadce0; border-radius: 0.3rem; padding: 0.75rem 1rem; overflow-x: auto; font-size: 0.875rem; }
+a { color: #007d9c; text-decoration: none; }
+a:hover { text-decoration: underline; }
+</style>
+</head>
+<body>
+<header>
+<h1>package {{.Package}}</h1>
+{{with .ImportPath}}<div class="import">import "{{.}}"</div>{{end}}
+</header>
+<main>
+<h2 id="pkg-overview">Overview</h2>
+{{.Doc}}
+</main>
+{{if .Reload}}<script>new EventSource("/events").onmessage = function() { location.reload(); };</script>{{end}}
+</body>
+</html>
+`))
+
+// writeText renders the package documentation in the generated source src as
+// text wrapped to width, the way go doc prints it.
+func writeText(w io.Writer, src []byte, importPath string, width int) error {
+	f, err := parseDoc(src)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "package %s", f.Name.Name)
+	if importPath != "" {
+		fmt.Fprintf(w, " // import %q", importPath)
+	}
+	fmt.Fprint(w, "\n\n")
+
+	var p comment.Parser
+	pr := comment.Printer{TextWidth: width}
+	_, err = w.Write(pr.Text(p.Parse(f.Doc.Text())))
+	return err
+}
+
+// textWidth returns the width to wrap text printed to w to: that of the
+// terminal, or $COLUMNS, but no wider than 100 columns to stay readable, and
+// 80 if neither is known.
+func textWidth(w io.Writer) int {
+	cols := 0
+	if f, ok := w.(*os.File); ok && isTerminal(w) {
+		cols = terminalColumns(f)
+	}
+	if cols == 0 {
+		cols, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	switch {
+	case cols <= 0:
+		return 80
+	case cols > 100:
+		return 100
+	}
+	return cols
+}
+
+// parseDoc parses the package clause and documentation of src.
+func parseDoc(src []byte) (*ast.File, error) {
+	return parser.ParseFile(token.NewFileSet(), "doc.go", src, parser.PackageClauseOnly|parser.ParseComments)
+}
+
+// writeHTML renders the package documentation in the generated source src to
+// an HTML page, with a script to reload it on changes if reload is set.
+func writeHTML(w io.Writer, src []byte, importPath string, reload bool) error {
+	f, err := parseDoc(src)
+	if err != nil {
+		return err
+	}
+	var p comment.Parser
+	pr := comment.Printer{DocLinkBaseURL: "https://pkg.go.dev"}
+	page := previewPage{
+		Package:    f.Name.Name,
+		ImportPath: importPath,
+		Doc:        template.HTML(pr.HTML(p.Parse(f.Doc.Text()))),
+		Reload:     reload,
+	}
+	return previewTemplate.Execute(w, page)
+}
+
+// previewHandler serves the HTML preview of the input, rendered afresh for
+// every request, and a stream of events telling the page to reload when the
+// input, license or configuration changes.
+func previewHandler(c *config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		// Rendering applies the configuration and front matter, so start
+		// from the command line options every time
+		rc := *c
+		output, err := rc.render(nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var buff bytes.Buffer
+		if err := writeHTML(&buff, output, rc.links().ImportPath, true); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(buff.Bytes())
+	})
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		// Take the modification times before the client knows it's
+		// connected, so that no change it makes after that is missed
+		files := c.watchedFiles()
+		last := modTimes(files)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		flusher.Flush()
+
+		ticker := time.NewTicker(reloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				if !sameTimes(modTimes(files), last) {
+					fmt.Fprint(w, "data: reload\n\n")
+					flusher.Flush()
+					return
+				}
+			}
+		}
+	})
+	return mux
+}
+
+// modTime returns the modification time of file, or the zero time if it
+// can't be read.
+func modTime(file string) time.Time {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
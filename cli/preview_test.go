@@ -0,0 +1,137 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPreview_HTML(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("# Title\n\nSome text.\n\n## Usage\n\nRun it:\n\n```go\nrun()\n```\n")
+
+	code := Run([]string{"preview", "-html", "-stdin", "-pkg", "foo", "-license=false"}, stdin, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "<h1>package foo</h1>")
+	assert.Contains(t, out, "<p>Package foo is the Title.\n")
+	assert.Contains(t, out, `<h3 id="hdr-Usage">Usage</h3>`)
+	assert.Contains(t, out, "<pre>run()\n</pre>")
+	assert.NotContains(t, out, "EventSource")
+}
+
+func TestRunPreview_Text(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("# Title\n\nSome text that goes on for long enough to be wrapped at the width of a narrow terminal.\n")
+
+	gopath, cleanup := tempDir(t)
+	defer cleanup()
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	os.Setenv("GOPATH", gopath)
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+	os.Setenv("COLUMNS", "40")
+	code := Run([]string{"preview", "-text", "-stdin", "-pkg", "foo", "-license=false"}, stdin, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "package foo\n\n"+
+		"Package foo is the Title.\n\n"+
+		"Some text that goes on for long enough\n"+
+		"to be wrapped at the width of a narrow\n"+
+		"terminal.\n",
+		stdout.String())
+}
+
+func TestWriteText_ImportPath(t *testing.T) {
+	var buff bytes.Buffer
+	require.NoError(t, writeText(&buff, []byte("// Text.\npackage foo\n"), "example.com/foo", 80))
+	assert.Equal(t, "package foo // import \"example.com/foo\"\n\nText.\n", buff.String())
+}
+
+func TestTextWidth(t *testing.T) {
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+	os.Setenv("COLUMNS", "200")
+	assert.Equal(t, 100, textWidth(&bytes.Buffer{}))
+
+	os.Setenv("COLUMNS", "")
+	assert.Equal(t, 80, textWidth(&bytes.Buffer{}))
+}
+
+func TestRunPreview_HTTPWithStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"preview", "-http", "localhost:0", "-stdin"}, nil, &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "-http can't be used with -stdin")
+}
+
+func TestPreviewHandler(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	input := filepath.Join(dir, "README.md")
+	require.NoError(t, ioutil.WriteFile(input, []byte("# Title\n\nSome text.\n"), 0644))
+
+	server := httptest.NewServer(previewHandler(parseConfig(t, "-input", input, "-pkg", "foo")))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "Package foo is the Title.")
+	assert.Contains(t, string(body), "EventSource")
+
+	resp, err = http.Get(server.URL + "/nope")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPreviewHandler_Reload(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	input := filepath.Join(dir, "README.md")
+	require.NoError(t, ioutil.WriteFile(input, []byte("Text.\n"), 0644))
+
+	server := httptest.NewServer(previewHandler(parseConfig(t, "-input", input, "-pkg", "foo")))
+	defer server.Close()
+
+	// Don't hang until the test binary times out if the reload never comes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequest("GET", server.URL+"/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(input, later, later))
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "data: reload\n", line)
+}
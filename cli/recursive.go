@@ -0,0 +1,228 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// genMode is what gen does with the output it renders.
+type genMode int
+
+const (
+	genWrite genMode = iota
+	genDryRun
+	genDiff
+)
+
+// genJob is the generation of one input in recursive mode. Its output is
+// buffered, so that it can be printed in order however the jobs are run.
+type genJob struct {
+	input  string
+	pkg    string
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+	failed bool
+	done   chan struct{}
+}
+
+// runRecursive generates the output for every input named like c.input
+// below its directory, with up to jobs inputs rendered at once.
+func runRecursive(c *config, mode genMode, jobs int, std stdio) int {
+	switch {
+	case c.stdin, c.stdout:
+		return fail(std, errors.New("-recursive can't be used with -stdin or -stdout"))
+	case c.set["output"]:
+		return fail(std, errors.New("-recursive can't be used with -output"))
+	case len(c.moreInputs) > 0:
+		return fail(std, errors.New("-recursive can't be used with more than one input"))
+	}
+	inputs, err := c.findInputs()
+	if err != nil {
+		return fail(std, err)
+	}
+
+	var names map[string]string
+	if c.pkg == "" {
+		if names, err = packageNames(filepath.Dir(c.input), inputs); err != nil {
+			return fail(std, err)
+		}
+	}
+	queue := make(chan *genJob, len(inputs))
+	all := make([]*genJob, len(inputs))
+	for i, input := range inputs {
+		all[i] = &genJob{input: input, pkg: c.pkg, done: make(chan struct{})}
+		if c.pkg == "" {
+			all[i].pkg = names[filepath.Dir(input)]
+		}
+		queue <- all[i]
+	}
+	close(queue)
+
+	if jobs < 1 {
+		jobs = 1
+	}
+	color := isTerminal(std.stdout)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				c.runJob(j, mode, color)
+				close(j.done)
+			}
+		}()
+	}
+
+	code := 0
+	for _, j := range all {
+		<-j.done
+		std.stdout.Write(j.stdout.Bytes())
+		std.stderr.Write(j.stderr.Bytes())
+		if j.failed {
+			code = 1
+		}
+	}
+	wg.Wait()
+	return code
+}
+
+// runJob renders the input of j with the options of c, then writes it out,
+// lists it or prints a diff depending on mode.
+func (c *config) runJob(j *genJob, mode genMode, color bool) {
+	if j.pkg == "" {
+		fmt.Fprintf(&j.stderr, "md-to-godoc: skipping %v: no Go package\n", j.input)
+		return
+	}
+	rc := *c
+	rc.input = j.input
+	rc.pkg = j.pkg
+	rc.warnings = &j.stderr
+	// The package name found for the directory wins over the configuration
+	// file, as one given with -pkg would
+	rc.set = make(map[string]bool, len(c.set)+1)
+	for name, set := range c.set {
+		rc.set[name] = set
+	}
+	rc.set["pkg"] = true
+
+	if mode == genWrite {
+		status, err := rc.update(nil)
+		if err != nil {
+			fmt.Fprintf(&j.stderr, "md-to-godoc: %v: %v\n", j.input, err)
+			j.failed = true
+		} else if status == "create" || status == "modify" {
+			fmt.Fprintf(&j.stdout, "%-9s %s\n", status, rc.outputPath())
+		}
+		return
+	}
+	p, err := rc.preview(nil)
+	if err != nil {
+		fmt.Fprintf(&j.stderr, "md-to-godoc: %v: %v\n", j.input, err)
+		j.failed = true
+		return
+	}
+	switch mode {
+	case genDryRun:
+		fmt.Fprintf(&j.stdout, "%-9s %s\n", p.status(), p.path)
+	case genDiff:
+		writeDiff(&j.stdout, p.path, p.current, p.output, color)
+	}
+}
+
+// findInputs returns the files named like the input in its directory and the
+// ones below it, in lexical order. Like the go command, it skips vendor and
+// testdata directories and those starting with a dot or an underscore.
+func (c *config) findInputs() ([]string, error) {
+	root, name := filepath.Dir(c.input), filepath.Base(c.input)
+	var inputs []string
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			base := fi.Name()
+			if path != root && (base == "vendor" || base == "testdata" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.Name() == name {
+			inputs = append(inputs, path)
+		}
+		return nil
+	})
+	sort.Strings(inputs)
+	return inputs, err
+}
+
+// packageNames looks up the names of the packages the inputs are in, keyed by
+// the directory of the input, loading them all at once with go/packages from
+// root, the directory the inputs were found in. Directories without a Go
+// package are left out.
+func packageNames(root string, inputs []string) (map[string]string, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	byAbs := make(map[string]string, len(inputs))
+	for _, input := range inputs {
+		dir := filepath.Dir(input)
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(absRoot, abs)
+		if err != nil {
+			return nil, err
+		}
+		byAbs[abs] = dir
+		// The go command only takes relative paths that start with a dot
+		patterns = append(patterns, "./"+filepath.ToSlash(rel))
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles,
+		Dir:  absRoot,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(inputs))
+	for _, pkg := range pkgs {
+		files := append(pkg.GoFiles, pkg.IgnoredFiles...)
+		if pkg.Name == "" || len(files) == 0 {
+			continue
+		}
+		if dir, ok := byAbs[filepath.Dir(files[0])]; ok {
+			names[dir] = pkg.Name
+		}
+	}
+	return names, nil
+}
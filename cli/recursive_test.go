@@ -0,0 +1,111 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTree creates packages with READMEs in dir, along with a README outside
+// of any package and one in a vendor directory.
+func testTree(t *testing.T, dir string) {
+	files := map[string]string{
+		"a/README.md":          "Alpha.\n",
+		"a/a.go":               "package alpha\n",
+		"b/README.md":          "Not Go.\n",
+		"c/README.md":          "Gamma.\n",
+		"c/c.go":               "package gamma\n",
+		"c/d/README.md":        "Delta.\n",
+		"c/d/d.go":             "package delta\n",
+		"vendor/v/README.md":   "Vendored.\n",
+		"vendor/v/v.go":        "package v\n",
+		".hidden/h/README.md":  "Hidden.\n",
+		"c/testdata/README.md": "Test data.\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+}
+
+func TestFindInputs(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	testTree(t, dir)
+
+	c := parseConfig(t, "-input", filepath.Join(dir, "README.md"))
+	inputs, err := c.findInputs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a/README.md"),
+		filepath.Join(dir, "b/README.md"),
+		filepath.Join(dir, "c/README.md"),
+		filepath.Join(dir, "c/d/README.md"),
+	}, inputs)
+}
+
+func TestRunGen_Recursive(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	testTree(t, dir)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-recursive", "-j", "2", "-input", filepath.Join(dir, "README.md"), "-license=false"}
+	assert.Equal(t, 0, Run(args, nil, &stdout, &stderr))
+	assert.Equal(t,
+		"create    "+filepath.Join(dir, "a/doc.go")+"\n"+
+			"create    "+filepath.Join(dir, "c/doc.go")+"\n"+
+			"create    "+filepath.Join(dir, "c/d/doc.go")+"\n",
+		stdout.String())
+	assert.Equal(t, "md-to-godoc: skipping "+filepath.Join(dir, "b/README.md")+": no Go package\n", stderr.String())
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "c/d/doc.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "// Package delta is the Delta.\npackage delta\n", string(contents))
+
+	stdout.Reset()
+	args = append(args, "-dry-run")
+	assert.Equal(t, 0, Run(args, nil, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "unchanged "+filepath.Join(dir, "a/doc.go")+"\n")
+}
+
+func TestRunGen_RecursiveConfigPackage(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	testTree(t, dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, configFile), []byte("pkg: wrong\n"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-recursive", "-input", filepath.Join(dir, "README.md"), "-license=false"}
+	require.Equal(t, 0, Run(args, nil, &stdout, &stderr), stderr.String())
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "c/d/doc.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "// Package delta is the Delta.\npackage delta\n", string(contents))
+}
+
+func TestRunGen_RecursiveOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, Run([]string{"-recursive", "-output", "x.go"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "-recursive can't be used with -output")
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"strings"
+)
+
+func runReverse(cmd *command, args []string, std stdio) int {
+	fs := cmd.flagSet(std.stderr)
+	input := fs.String("input", "doc.go", "Go file to read package documentation from")
+	output := fs.String("output", "", "Path to write markdown to. If empty, write to STDOUT")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	md, err := reverse(*input)
+	if err != nil {
+		return fail(std, err)
+	}
+	if *output == "" {
+		std.stdout.Write(md)
+		return 0
+	}
+	if err := ioutil.WriteFile(*output, md, 0644); err != nil {
+		return fail(std, err)
+	}
+	return 0
+}
+
+// reverse converts the package documentation of a Go file to markdown. The
+// "Package foo is the Title." synopsis generated by md-to-godoc becomes a
+// heading again.
+func reverse(file string) ([]byte, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if f.Doc == nil {
+		return nil, fmt.Errorf("%v has no package documentation", file)
+	}
+
+	var p comment.Parser
+	doc := p.Parse(f.Doc.Text())
+
+	var title string
+	prefix := "Package " + f.Name.Name + " is the "
+	if len(doc.Content) > 0 {
+		if para, ok := doc.Content[0].(*comment.Paragraph); ok && len(para.Text) == 1 {
+			if plain, ok := para.Text[0].(comment.Plain); ok && strings.HasPrefix(string(plain), prefix) && strings.HasSuffix(string(plain), ".") {
+				title = strings.TrimSuffix(strings.TrimPrefix(string(plain), prefix), ".")
+				doc.Content = doc.Content[1:]
+			}
+		}
+	}
+
+	pr := comment.Printer{
+		HeadingLevel: 2,
+		HeadingID: func(*comment.Heading) string {
+			return ""
+		},
+	}
+	md := pr.Markdown(doc)
+	if title != "" {
+		md = append([]byte("# "+title+"\n\n"), md...)
+	}
+	return md, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverse(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	file := filepath.Join(dir, "doc.go")
+	src := "// Copyright 2016\n\n// Package foo is the Foo thing.\n//\n// Some text.\n//\n// Usage\n//\n// Call it:\n//\n//   foo.Bar()\n//\npackage foo\n"
+	require.NoError(t, ioutil.WriteFile(file, []byte(src), 0644))
+
+	md, err := reverse(file)
+	require.NoError(t, err)
+	assert.Equal(t, "# Foo thing\n\nSome text.\n\n## Usage\n\nCall it:\n\n\tfoo.Bar()\n", string(md))
+}
+
+func TestReverse_NoDoc(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	file := filepath.Join(dir, "doc.go")
+	require.NoError(t, ioutil.WriteFile(file, []byte("package foo\n"), 0644))
+
+	_, err := reverse(file)
+	assert.Error(t, err)
+}
+
+func TestRunReverse(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	file := filepath.Join(dir, "doc.go")
+	src := "// Package foo is the Foo thing.\n//\n// Some text.\npackage foo\n"
+	require.NoError(t, ioutil.WriteFile(file, []byte(src), 0644))
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"reverse", "-input", file}, nil, &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "# Foo thing\n\nSome text.\n", stdout.String())
+}
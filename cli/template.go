@@ -0,0 +1,92 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sectioneight/md-to-godoc/render"
+)
+
+// templateData is what templates in the input and license are expanded with,
+// when -template is set.
+type templateData struct {
+	// ImportPath is the import path of the package, if it's in a module or
+	// the GOPATH.
+	ImportPath string
+	// Package is the name of the package.
+	Package string
+
+	version string
+	dir     string
+}
+
+// gitDescribe returns what git describe says about the commit checked out in
+// dir. It's a variable so that tests don't depend on being run in a checkout.
+var gitDescribe = func(dir string) (string, error) {
+	cmd := exec.Command("git", "describe", "--tags", "--always")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Version returns the version of the project: -version, or the version in the
+// front matter, or failing that what git describe says about the directory of
+// the input.
+func (d templateData) Version() (string, error) {
+	if d.version != "" {
+		return d.version, nil
+	}
+	version, err := gitDescribe(d.dir)
+	if err != nil {
+		return "", errors.New("no version: set -version or version in the front matter, or run in a git repository")
+	}
+	return version, nil
+}
+
+func (c *config) templateData(pkg string, fm render.FrontMatter) templateData {
+	version := c.version
+	if version == "" {
+		version = fm.Version
+	}
+	return templateData{
+		ImportPath: c.links().ImportPath,
+		Package:    pkg,
+		version:    version,
+		dir:        filepath.Dir(c.input),
+	}
+}
+
+// expand runs the inputs through their templates, if -template is set.
+func (c *config) expand(docs []render.Document, data templateData) error {
+	if !c.template {
+		return nil
+	}
+	for i, doc := range docs {
+		expanded, err := render.Expand(doc.Markdown, filepath.Dir(doc.Name), data)
+		if err != nil {
+			return fmt.Errorf("could not expand template in %v: %v", doc.Name, err)
+		}
+		docs[i].Markdown = expanded
+	}
+	return nil
+}
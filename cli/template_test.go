@@ -0,0 +1,100 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_Template(t *testing.T) {
+	defer stubGitDescribe("v1.2.3-4-gabcdef", nil)()
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("Package {{.Package}} at {{.Version}}.\n")
+
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-license=false", "-template"}, stdin, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "// Package foo is the Package foo at v1.2.3-4-gabcdef.\npackage foo\n", stdout.String())
+}
+
+func TestRun_TemplateVersion(t *testing.T) {
+	defer stubGitDescribe("", errors.New("not a git repository"))()
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		want  string
+	}{
+		{"flag", []string{"-version", "v2.0.0"}, "{{.Version}}.\n", "v2.0.0."},
+		{"front matter", nil, "---\nversion: v1.0.0\n---\n{{.Version}}.\n", "v1.0.0."},
+		{"flag wins", []string{"-version", "v2.0.0"}, "---\nversion: v1.0.0\n---\n{{.Version}}.\n", "v2.0.0."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"-stdin", "-stdout", "-pkg", "foo", "-license=false", "-template"}, tt.args...)
+			code := Run(args, strings.NewReader(tt.input), &stdout, &stderr)
+			require.Equal(t, 0, code, stderr.String())
+			assert.Contains(t, stdout.String(), tt.want)
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-license=false", "-template"}, strings.NewReader("{{.Version}}.\n"), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "no version")
+}
+
+// stubGitDescribe makes gitDescribe return version and err, returning a
+// function that restores it.
+func stubGitDescribe(version string, err error) func() {
+	orig := gitDescribe
+	gitDescribe = func(string) (string, error) { return version, err }
+	return func() { gitDescribe = orig }
+}
+
+func TestRun_TemplateOff(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("Braces {{.Package}}.\n")
+
+	code := Run([]string{"-stdin", "-stdout", "-pkg", "foo", "-license=false"}, stdin, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "{{.Package}}")
+}
+
+func TestRun_TemplateLicense(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	repo := filepath.Join(dir, "src", "example.com", "repo")
+	require.NoError(t, os.MkdirAll(repo, 0755))
+	license := filepath.Join(repo, "LICENSE.txt")
+	require.NoError(t, ioutil.WriteFile(license, []byte("{{.ImportPath}} is licensed.\n"), 0644))
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	os.Setenv("GOPATH", dir)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", filepath.Join(repo, "README.md"), "-stdin", "-stdout", "-pkg", "repo", "-licenseFile", license, "-template"}
+	code := Run(args, strings.NewReader("Text.\n"), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.True(t, strings.HasPrefix(stdout.String(), "// example.com/repo is licensed.\n"), stdout.String())
+}
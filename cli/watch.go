@@ -0,0 +1,118 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// watchInterval is how often -watch polls the files the output is made
+	// from.
+	watchInterval = 250 * time.Millisecond
+	// watchDebounce is how long the files must stay unchanged before the
+	// output is regenerated, so that a burst of saves regenerates it once.
+	watchDebounce = 500 * time.Millisecond
+)
+
+// watch regenerates the output whenever the input, license file or
+// configuration file changes, until stop is closed. Regenerations and their
+// errors are logged to w; rendering warnings go where they always do.
+func (c *config) watch(stop <-chan struct{}, stdout, w io.Writer) {
+	logger := log.New(w, "md-to-godoc: ", log.Ltime)
+	files := c.regenerate(stdout, logger)
+	last := modTimes(files)
+
+	var changed time.Time // when a change was last seen, zero if none is pending
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if cur := modTimes(files); !sameTimes(cur, last) {
+				last = cur
+				changed = now
+				continue
+			}
+			if !changed.IsZero() && now.Sub(changed) >= watchDebounce {
+				changed = time.Time{}
+				files = c.regenerate(stdout, logger)
+				last = modTimes(files)
+			}
+		}
+	}
+}
+
+// regenerate renders and writes the output once, logging what happened, and
+// returns the files to watch for the next change. The configuration and front
+// matter may change between runs, so it starts from the command line options
+// every time.
+func (c *config) regenerate(stdout io.Writer, logger *log.Logger) []string {
+	rc := *c
+	if rc.stdout {
+		if err := rc.generate(nil, stdout); err != nil {
+			logger.Printf("error: %v", err)
+		}
+		return rc.watchedFiles()
+	}
+	status, err := rc.update(nil)
+	if err != nil {
+		logger.Printf("error: %v", err)
+		return rc.watchedFiles()
+	}
+	logger.Printf("%-9s %s", status, rc.outputPath())
+	return rc.watchedFiles()
+}
+
+// watchedFiles returns the files the output is made from.
+func (c *config) watchedFiles() []string {
+	files := append([]string{c.input}, c.moreInputs...)
+	if c.license {
+		files = append(files, c.licenseFile)
+	}
+	if dir, err := filepath.Abs(filepath.Dir(c.input)); err == nil {
+		if file := findConfig(dir); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// modTimes returns the modification times of files, zero for the ones that
+// don't exist, so that creating them counts as a change too.
+func modTimes(files []string) []time.Time {
+	times := make([]time.Time, len(files))
+	for i, file := range files {
+		times[i] = modTime(file)
+	}
+	return times
+}
+
+func sameTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
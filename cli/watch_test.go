@@ -0,0 +1,106 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a buffer that is safe to read while the watcher writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatch(t *testing.T) {
+	defer func(interval, debounce time.Duration) {
+		watchInterval, watchDebounce = interval, debounce
+	}(watchInterval, watchDebounce)
+	watchInterval, watchDebounce = 10*time.Millisecond, 30*time.Millisecond
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	input := filepath.Join(dir, "README.md")
+	output := filepath.Join(dir, "doc.go")
+	require.NoError(t, ioutil.WriteFile(input, []byte("First.\n"), 0644))
+
+	var log syncBuffer
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		parseConfig(t, "-input", input, "-pkg", "foo", "-license=false").watch(stop, nil, &log)
+		close(done)
+	}()
+
+	waitFor(t, func() bool { return strings.Contains(log.String(), "create") })
+	require.NoError(t, ioutil.WriteFile(input, []byte("Second.\n"), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(input, later, later))
+	waitFor(t, func() bool { return strings.Contains(log.String(), "modify") })
+	close(stop)
+	<-done
+
+	contents, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "Package foo is the Second.")
+	assert.Equal(t, 2, strings.Count(log.String(), "\n"), log.String())
+}
+
+func TestWatchedFiles(t *testing.T) {
+	c := parseConfig(t, "-input", "render/README.md", "-licenseFile", "LICENSE.txt")
+	assert.Equal(t, []string{"render/README.md", "LICENSE.txt"}, c.watchedFiles())
+
+	c = parseConfig(t, "-license=false")
+	assert.Equal(t, []string{"README.md"}, c.watchedFiles())
+}
+
+func TestRunGen_WatchStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, Run([]string{"-watch", "-stdin"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "-watch can't be used with -stdin")
+}
+
+// waitFor waits a second at most for cond to hold.
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// writeFile atomically replaces the contents of file with data. Data is
+// written to a temporary file in the same directory, which is then renamed
+// into place, so an interrupted run never leaves a truncated file behind.
+// The permissions of an existing file are preserved, new files get 0666 less
+// the umask like os.Create gives them, and a file that already has the same
+// contents isn't touched at all, keeping its modification time stable for
+// build caches.
+func writeFile(file string, data []byte) (err error) {
+	var mode os.FileMode // zero for a new file
+	if fi, err := os.Stat(file); err == nil {
+		mode = fi.Mode().Perm()
+		if current, err := ioutil.ReadFile(file); err == nil && bytes.Equal(current, data) {
+			return nil
+		}
+		// Replace the target of a symlink, rather than the link itself
+		if resolved, err := filepath.EvalSymlinks(file); err == nil {
+			file = resolved
+		}
+	}
+
+	tmp, err := createTemp(filepath.Dir(file), "."+filepath.Base(file)+".")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if mode != 0 {
+		if err = os.Chmod(tmp.Name(), mode); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// createTemp creates a new file in dir whose name starts with prefix. Unlike
+// ioutil.TempFile, which always uses 0600, it's created with 0666 less the
+// umask.
+func createTemp(dir, prefix string) (*os.File, error) {
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
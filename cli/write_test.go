@@ -0,0 +1,107 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile_New(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	file := filepath.Join(dir, "doc.go")
+
+	require.NoError(t, writeFile(file, []byte("package foo\n")))
+
+	contents, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "package foo\n", string(contents))
+
+	// The umask applies, as it does to files made with os.Create
+	ref, err := os.Create(filepath.Join(dir, "ref"))
+	require.NoError(t, err)
+	ref.Close()
+	refInfo, err := os.Stat(ref.Name())
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(ref.Name()))
+	fi, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, refInfo.Mode().Perm(), fi.Mode().Perm())
+
+	// No temporary files are left behind
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFile_PreservesMode(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	file := filepath.Join(dir, "doc.go")
+	require.NoError(t, ioutil.WriteFile(file, []byte("package foo\n"), 0600))
+
+	require.NoError(t, writeFile(file, []byte("package bar\n")))
+
+	fi, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	contents, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "package bar\n", string(contents))
+}
+
+func TestWriteFile_Unchanged(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	file := filepath.Join(dir, "doc.go")
+	require.NoError(t, ioutil.WriteFile(file, []byte("package foo\n"), 0644))
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(file, past, past))
+
+	require.NoError(t, writeFile(file, []byte("package foo\n")))
+
+	fi, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.True(t, fi.ModTime().Equal(past), "modification time should be left alone")
+}
+
+func TestWriteFile_Symlink(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	target := filepath.Join(dir, "target.go")
+	link := filepath.Join(dir, "doc.go")
+	require.NoError(t, ioutil.WriteFile(target, []byte("package foo\n"), 0644))
+	require.NoError(t, os.Symlink(target, link))
+
+	require.NoError(t, writeFile(link, []byte("package bar\n")))
+
+	fi, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.True(t, fi.Mode()&os.ModeSymlink != 0, "link should be kept")
+	contents, err := ioutil.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "package bar\n", string(contents))
+}
+
+func TestWriteFile_BadDir(t *testing.T) {
+	assert.Error(t, writeFile(filepath.Join("non-existent", "doc.go"), nil))
+}
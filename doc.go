@@ -17,53 +17,150 @@
 // Sort of like godocdown (https://github.com/robertkrimen/godocdown), but in
 // reverse.
 //
-//
 // md-to-godoc takes markdown as input, and generates godoc-formatted package
 // documentation.
 //
+// # Status
 //
-// Status
-//
-// Way, **way** alpha. Barebones. The minimalest.
+// Way, way alpha. Barebones. The minimalest.
 //
-// Code example
+// # Code example
 //
 // Mostly here so we can see some code in godoc:
 //
-// Sample list
+// # Sample list
 //
 // • This is a test
 //
 // • And another test
 //
-//   func main() {
-//     fmt.Println("Hello, world")
-//   }
+//	func main() {
+//	  fmt.Println("Hello, world")
+//	}
 //
-// Usage
+// # Usage
 //
 // First, install the binary:
 //
-//   go get -u github.com/sectioneight/md-to-godoc
+//	go get -u github.com/sectioneight/md-to-godoc
 //
 // Then, run it on one or more packages. If you'd like to generate a doc.go file
-// in the current package (that already has a
-// README.md), simply run
+// in the current package (that already has a README.md), simply run
 // md-to-godoc with no flags:
 //
-//   md-to-godoc
+//	md-to-godoc
+//
+// # Commands
+//
+// md-to-godoc with no command runs gen. With -watch, it keeps running and
+// regenerates doc.go whenever the README, license or configuration changes.
+// The other commands are:
+//
+// • check exits with a non-zero status if doc.go is out of date, and warns
+// if it was edited by hand after being generated with -generated-header,
+// which starts it with a // Code generated ... DO NOT EDIT. header
+//
+// • diff shows what gen would change
+//
+// • reverse turns the package documentation in doc.go back into markdown
+//
+// • preview prints the generated source without writing it, or with -html
+// the documentation as pkg.go.dev would show it; -http localhost:6060 serves
+// that page and reloads it whenever the input changes, while -text prints it
+// the way go doc does
+//
+// • examples turns the Go code blocks into Example functions in
+// example_readme_test.go, so that go test checks them
+//
+// • init writes a starter .md-to-godoc.yaml
+//
+// Run md-to-godoc help <command> for the flags of each command.
+//
+// # Several inputs
+//
+// -input takes a comma separated list of files or globs, such as
+// README.md,docs/*.md. They are rendered in order into one package comment,
+// and headings that appear in more than one of them are reported. With
+// -demote-headings, the headings of every file but the first move down a
+// level, so that they nest under its title, or N levels with
+// -demote-headings=N. The same number goes in the configuration file, where
+// true means one level and false none. A file can set demote-headings: N
+// in its front matter too, to move its own headings down by that many levels.
+// Otherwise, only the front matter of the first file is used.
+// Relative links in each file are resolved against the directory it's in.
+//
+// # Synopsis
+//
+// The first sentence of the documentation is the package synopsis, shown in
+// search results on pkg.go.dev. It's "Package foo is the Title." for a README
+// whose leading heading is Title, unless the front matter sets synopsis. With
+// -synopsis-from paragraph it's the first paragraph instead, and with
+// -synopsis-from front-matter a missing synopsis is reported. Synopses
+// longer than -synopsis-limit characters, with URLs or markdown left in them,
+// or not ending with a period are reported too.
 //
-// Advanced usage
+// # Contents
 //
-// To generate doc.go for all subpackages, you can do something like the
-// following:
+// With -toc, a list of the sections follows the synopsis, leaving out any
+// removed by -exclude-section or -include-section. To put it somewhere else,
+// add a <!-- toc --> comment there. A hand-written contents list right after
+// the comment, or up to a <!-- tocstop --> comment, is replaced by the
+// generated one.
 //
+// # Templates
 //
-//   find . -name README.md \
-//          -not -path "./vendor/*" | \
-//          xargs -I% md-to-godoc -input=%
+// With -template, each input is expanded as a Go text/template before it's
+// parsed. {{.ImportPath}} and {{.Package}} are the import path and name of
+// the package, and {{.Version}} is the value of -version, or of version in
+// the front matter, or else what git describe --tags says.
+// {{include "example_test.go" "basic"}} includes the lines of a file between
+// // region basic and // endregion basic. The license header is expanded the
+// same way.
 //
-// Projects using md-to-godoc
+// The import path comes from the nearest go.mod, or outside a module from
+// where the package sits in the GOPATH. It's also used to link to other
+// packages and in the package line of preview -text.
+//
+// # Examples
+//
+// Code blocks tagged example=Name become the function ExampleName, and an
+// output code block right after one becomes its expected output:
+//
+//	```go example=Provide
+//	fmt.Println(pkg.Provide())
+//	```
+//
+//	```output
+//	provided
+//	```
+//
+// Untagged Go code blocks become Example_readme, Example_readme2 and so on,
+// if they are statements that fit in a function. The package itself and the
+// standard library packages the examples use are imported for them, but others
+// need an import declaration at the top of the code block. Without any Go code
+// blocks, no file is written.
+//
+// # Advanced usage
+//
+// To generate doc.go for every package with a README.md in or below the
+// current directory, skipping vendor and testdata, run:
+//
+//	md-to-godoc -recursive
+//
+// Package names are looked up in one batch with go/packages, and packages are
+// rendered in parallel, as many at once as there are CPUs unless -j N says
+// otherwise. -dry-run and -diff work across packages too.
+//
+// gen keeps a hash of everything each doc.go is made from in the user's cache
+// directory, and skips packages where none of it has changed. Use -force to
+// render them anyway. Packages rendered with warnings are never skipped, so the
+// warnings keep showing until they're fixed.
+//
+// To bundle md-to-godoc into another command line tool, import
+// github.com/sectioneight/md-to-godoc/cli and hand the arguments after your
+// own subcommand to cli.Run, which returns the exit code.
+//
+// # Projects using md-to-godoc
 //
 // • UberFx, on GitHub (https://github.com/uber-go/fx) and
 // godoc.org (https://godoc.org/go.uber.org/fx)
@@ -71,9 +168,7 @@
 // • Jaeger, on Github (https://github.com/uber/jaeger) and
 // godoc.org (https://godoc.org/github.com/uber/jaeger/services/agent)
 //
-// Licence
+// # Licence
 //
 // Apache 2.0 (https://www.apache.org/licenses/LICENSE-2.0)
-//
-//
 package main
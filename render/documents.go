@@ -0,0 +1,148 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/russross/blackfriday"
+)
+
+// Document is a markdown document, without front matter, to be rendered
+// along with others by GenerateDocuments.
+type Document struct {
+	// Name identifies the document in warnings, such as its file name.
+	Name string
+	// Dir is the directory of the document relative to the package, such as
+	// docs, that its relative links are resolved against. Empty for the
+	// package directory.
+	Dir      string
+	Markdown []byte
+	// DemoteHeadings, if set, is the number of levels the headings of this
+	// document are moved down by, overriding Options.DemoteHeadings.
+	DemoteHeadings *Demotion
+}
+
+// Demotion is a number of levels to move headings down by. In front matter,
+// configuration files and flags it's a number, or true for one level and
+// false for none.
+type Demotion int
+
+// UnmarshalText parses a number of levels, true or false.
+func (d *Demotion) UnmarshalText(text []byte) error {
+	if b, err := strconv.ParseBool(string(text)); err == nil {
+		*d = 0
+		if b {
+			*d = 1
+		}
+		return nil
+	}
+	n, err := strconv.Atoi(string(text))
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid demote-headings %q: must be a number of levels, true or false", text)
+	}
+	*d = Demotion(n)
+	return nil
+}
+
+// String, Set and IsBoolFlag make a Demotion a flag, which given without a
+// value means one level.
+func (d *Demotion) String() string {
+	return strconv.Itoa(int(*d))
+}
+
+func (d *Demotion) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+func (d *Demotion) IsBoolFlag() bool {
+	return true
+}
+
+// demotion returns the number of levels to demote the headings of the i-th
+// document by.
+func (doc Document) demotion(i int, opts Options) int {
+	switch {
+	case doc.DemoteHeadings != nil:
+		return int(*doc.DemoteHeadings)
+	case i > 0:
+		return int(opts.DemoteHeadings)
+	}
+	return 0
+}
+
+// mergeDocuments parses docs and appends them all to the first one,
+// demoting their headings as asked and rebasing their relative links onto
+// the package directory. Headings that appear in more than one document are
+// reported through opts.Warnf.
+func mergeDocuments(docs []Document, opts Options) *blackfriday.Node {
+	var ast *blackfriday.Node
+	seen := make(map[string]string) // heading text to the document it's in
+	for i, doc := range docs {
+		parsed := parse(doc.Markdown)
+		demote := doc.demotion(i, opts)
+		parsed.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+			if entering && node.Type == blackfriday.Link {
+				node.LinkData.Destination = rebaseLink(node.LinkData.Destination, doc.Dir)
+			}
+			if !entering || node.Type != blackfriday.Header {
+				return blackfriday.GoToNext
+			}
+			node.Level += demote
+			switch {
+			case node.Level > 6:
+				node.Level = 6
+			case node.Level < 1:
+				node.Level = 1
+			}
+			text := string(headingText(node))
+			if other, ok := seen[text]; ok && other != doc.Name && opts.Warnf != nil {
+				opts.Warnf("heading %q in %s is also in %s", text, doc.Name, other)
+			} else if !ok {
+				seen[text] = doc.Name
+			}
+			return blackfriday.SkipChildren
+		})
+
+		if ast == nil {
+			ast = parsed
+			continue
+		}
+		for child := parsed.FirstChild; child != nil; child = parsed.FirstChild {
+			ast.AppendChild(child)
+		}
+	}
+	if ast == nil {
+		return parse(nil)
+	}
+	return ast
+}
+
+// rebaseLink makes a relative link destination in a document in dir relative
+// to the package directory instead. Anything else is returned untouched.
+func rebaseLink(dest []byte, dir string) []byte {
+	if dir == "" || dir == "." {
+		return dest
+	}
+	u, err := url.Parse(string(dest))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || path.IsAbs(u.Path) {
+		return dest
+	}
+	u.Path = path.Join(dir, u.Path)
+	return []byte(u.String())
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDocuments(t *testing.T) {
+	docs := []Document{
+		{Name: "README.md", Markdown: []byte("# Title\n\nIntro.\n\n## Usage\n\nRun it.\n")},
+		{Name: "USAGE.md", Markdown: []byte("# Usage\n\nMore.\n\n## Flags\n\nNone.\n")},
+	}
+
+	var warnings []string
+	out, err := GenerateDocuments(docs, Options{
+		Package:        "pkg",
+		DemoteHeadings: 1,
+		Sections:       SectionFilter{Exclude: []*regexp.Regexp{regexp.MustCompile("^Flags$")}},
+		Warnf: func(format string, args ...interface{}) {
+			warnings = append(warnings, fmt.Sprintf(format, args...))
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"// Package pkg is the Title.\n//\n// Intro.\n//\n// # Usage\n//\n// Run it.\n//\n"+
+			"// # Usage\n//\n// More.\npackage pkg\n",
+		string(out),
+	)
+	assert.Equal(t, []string{`heading "Usage" in USAGE.md is also in README.md`}, warnings)
+}
+
+func TestMergeDocuments_Demote(t *testing.T) {
+	docs := []Document{
+		{Name: "a", Markdown: []byte("# A\n")},
+		{Name: "b", Markdown: []byte("# B\n\n###### Deep\n")},
+	}
+	levels := func(opts Options) []int {
+		var levels []int
+		for node := mergeDocuments(docs, opts).FirstChild; node != nil; node = node.Next {
+			levels = append(levels, node.Level)
+		}
+		return levels
+	}
+	assert.Equal(t, []int{1, 1, 6}, levels(Options{}))
+	assert.Equal(t, []int{1, 2, 6}, levels(Options{DemoteHeadings: 1}))
+
+	assert.Equal(t, []int{1, 3, 6}, levels(Options{DemoteHeadings: 2}))
+
+	two, none := Demotion(2), Demotion(0)
+	docs[0].DemoteHeadings = &two
+	docs[1].DemoteHeadings = &none
+	assert.Equal(t, []int{3, 1, 6}, levels(Options{DemoteHeadings: 1}))
+}
+
+func TestDemotion(t *testing.T) {
+	for text, want := range map[string]Demotion{"true": 1, "false": 0, "0": 0, "1": 1, "3": 3} {
+		var d Demotion
+		require.NoError(t, d.UnmarshalText([]byte(text)), text)
+		assert.Equal(t, want, d, text)
+	}
+	for _, text := range []string{"-1", "maybe", ""} {
+		var d Demotion
+		assert.Error(t, d.UnmarshalText([]byte(text)), text)
+	}
+}
+
+func TestMergeDocuments_Links(t *testing.T) {
+	docs := []Document{
+		{Name: "README.md", Markdown: []byte("See [usage](docs/USAGE.md).\n")},
+		{Name: "docs/USAGE.md", Dir: "docs", Markdown: []byte("See [design](DESIGN.md#goals), [contributing](../CONTRIBUTING.md), [Go](https://golang.org) and [flags](#flags).\n")},
+	}
+	out, err := GenerateDocuments(docs, Options{
+		Package: "pkg",
+		Links:   Links{RepoURL: "https://github.com/x/y", Dir: "pkg"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "usage (https://github.com/x/y/blob/master/pkg/docs/USAGE.md)")
+	assert.Contains(t, string(out), "design (https://github.com/x/y/blob/master/pkg/docs/DESIGN.md#goals)")
+	assert.Contains(t, string(out), "contributing (https://github.com/x/y/blob/master/pkg/CONTRIBUTING.md)")
+	assert.Contains(t, string(out), "Go (https://golang.org)")
+	assert.Contains(t, string(out), "flags (#flags)")
+}
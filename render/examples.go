@@ -0,0 +1,302 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/russross/blackfriday"
+)
+
+// ExampleOptions configure the extraction of examples from a document.
+type ExampleOptions struct {
+	// Package is the name of the package the examples are for. They are
+	// written to its external test package.
+	Package string
+	// ImportPath of the package, imported by the examples if they refer to
+	// it. Examples may also import it themselves.
+	ImportPath string
+	// License is written above the package clause, as with Options.
+	License []byte
+}
+
+// example is a fenced Go code block turned into an Example function.
+type example struct {
+	name    string
+	imports []string // import specs, such as "fmt" or f "fmt"
+	body    []byte
+	output  []byte // nil if there's no output block
+}
+
+// Examples extracts the Go code blocks of a markdown document into the source
+// of an example test file, so that go test compiles and runs them.
+//
+// A block tagged with example=Name in its info string, as in
+// "```go example=Provide", becomes the function ExampleProvide. Untagged blocks
+// become Example_readme, Example_readme2 and so on, but only if they are
+// statements that fit in a function body; the rest, such as type
+// declarations, are skipped. Blocks may start with import declarations, which
+// are moved to the top of the file. Standard library packages they use without
+// importing are imported for them, unless the name is ambiguous, as with rand.
+// A fenced block tagged output right after a Go block becomes its
+// "// Output:" comment.
+//
+// If there are no examples, Examples returns nil rather than an empty file.
+func Examples(input []byte, opts ExampleOptions) ([]byte, error) {
+	doc := parse(input)
+
+	var examples []example
+	var err error
+	seen := make(map[string]bool)
+	untagged := 0
+	doc.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || node.Type != blackfriday.CodeBlock || !isGo(string(node.Info)) {
+			return blackfriday.GoToNext
+		}
+		name, tagged := exampleName(string(node.Info))
+		ex, ok := parseExample(node.Literal)
+		if !ok {
+			if tagged {
+				err = fmt.Errorf("example %v is not a list of statements", name)
+				return blackfriday.Terminate
+			}
+			return blackfriday.GoToNext
+		}
+		if !tagged {
+			untagged++
+			name = "Example_readme"
+			if untagged > 1 {
+				name += strconv.Itoa(untagged)
+			}
+		}
+		if seen[name] {
+			err = fmt.Errorf("duplicate example %v", name)
+			return blackfriday.Terminate
+		}
+		seen[name] = true
+		ex.name = name
+		if next := node.Next; next != nil && next.Type == blackfriday.CodeBlock && infoLang(string(next.Info)) == "output" {
+			ex.output = next.Literal
+		}
+		examples = append(examples, ex)
+		return blackfriday.GoToNext
+	})
+	if err != nil || len(examples) == 0 {
+		return nil, err
+	}
+	return writeExamples(examples, opts)
+}
+
+// exampleName returns the function name asked for by an example= attribute
+// in info, and whether there was one.
+func exampleName(info string) (string, bool) {
+	for _, field := range strings.Fields(info) {
+		if strings.HasPrefix(field, "example=") {
+			return "Example" + strings.TrimPrefix(field, "example="), true
+		}
+	}
+	return "", false
+}
+
+// parseExample splits the leading import declarations off code, returning
+// false if the rest isn't valid as a function body.
+func parseExample(code []byte) (example, bool) {
+	var ex example
+	const header = "package p\n"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", header+string(code), parser.ImportsOnly)
+	if err != nil {
+		return ex, false
+	}
+	body := code
+	if len(f.Decls) > 0 {
+		end := fset.Position(f.Decls[len(f.Decls)-1].End()).Offset - len(header)
+		body = code[end:]
+	}
+	for _, spec := range f.Imports {
+		imp := spec.Path.Value
+		if spec.Name != nil {
+			imp = spec.Name.Name + " " + imp
+		}
+		ex.imports = append(ex.imports, imp)
+	}
+
+	src := "package p\nfunc _() {\n" + string(body) + "\n}\n"
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, 0); err != nil {
+		return ex, false
+	}
+	ex.body = bytes.TrimSpace(body)
+	return ex, true
+}
+
+func writeExamples(examples []example, opts ExampleOptions) ([]byte, error) {
+	var buff bytes.Buffer
+	writeLicense(&buff, opts.License)
+	fmt.Fprintf(&buff, "package %s_test\n\n", opts.Package)
+
+	var funcs bytes.Buffer
+	imports := make(map[string]bool)
+	for _, ex := range examples {
+		for _, imp := range ex.imports {
+			imports[imp] = true
+		}
+		fmt.Fprintf(&funcs, "\nfunc %s() {\n%s\n", ex.name, ex.body)
+		if ex.output != nil {
+			funcs.WriteString("// Output:\n")
+			s := bufio.NewScanner(bytes.NewReader(ex.output))
+			for s.Scan() {
+				fmt.Fprintf(&funcs, "// %s\n", s.Text())
+			}
+		}
+		funcs.WriteString("}\n")
+	}
+	imported := importNames(imports)
+	for name := range qualifiers(funcs.Bytes()) {
+		switch {
+		case imported[name]:
+		case name == opts.Package:
+			if opts.ImportPath != "" {
+				imports[strconv.Quote(opts.ImportPath)] = true
+			}
+		default:
+			if pkg := stdlibPackage(name); pkg != "" {
+				imports[strconv.Quote(pkg)] = true
+			}
+		}
+	}
+
+	if len(imports) > 0 {
+		var specs []string
+		for imp := range imports {
+			specs = append(specs, imp)
+		}
+		sort.Strings(specs)
+		fmt.Fprintf(&buff, "import (\n%s\n)\n", strings.Join(specs, "\n"))
+	}
+	buff.Write(funcs.Bytes())
+
+	out, err := format.Source(buff.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid examples: %v", err)
+	}
+	return out, nil
+}
+
+// importNames returns the names that the import specs make packages
+// available under.
+func importNames(imports map[string]bool) map[string]bool {
+	names := make(map[string]bool)
+	for imp := range imports {
+		if i := strings.IndexByte(imp, ' '); i >= 0 {
+			names[imp[:i]] = true
+			continue
+		}
+		if p, err := strconv.Unquote(imp); err == nil {
+			names[path.Base(p)] = true
+		}
+	}
+	return names
+}
+
+// qualifiers returns the names that qualify selectors in the functions in src,
+// such as fmt in fmt.Println, without being declared there: the packages they
+// need.
+func qualifiers(src []byte) map[string]bool {
+	names := make(map[string]bool)
+	f, err := parser.ParseFile(token.NewFileSet(), "", append([]byte("package p\n"), src...), 0)
+	if err != nil {
+		return names
+	}
+	unresolved := make(map[*ast.Ident]bool)
+	for _, id := range f.Unresolved {
+		unresolved[id] = true
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && unresolved[id] {
+				names[id.Name] = true
+			}
+		}
+		return true
+	})
+	return names
+}
+
+var (
+	stdlibOnce     sync.Once
+	stdlibPackages map[string][]string // import paths by package name
+)
+
+// stdlibPackage returns the import path of the standard library package
+// called name, or an empty string if there isn't one. Of several, such as
+// text/template and html/template, the one nearest the root wins, and none if
+// that's still a tie.
+func stdlibPackage(name string) string {
+	stdlibOnce.Do(func() {
+		stdlibPackages = make(map[string][]string)
+		src := filepath.Join(build.Default.GOROOT, "src")
+		seen := make(map[string]bool)
+		filepath.Walk(src, func(file string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(src, file)
+			rel = filepath.ToSlash(rel)
+			if fi.IsDir() {
+				switch fi.Name() {
+				case "internal", "vendor", "testdata":
+					return filepath.SkipDir
+				}
+				if rel == "cmd" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if dir := path.Dir(rel); dir != "." && !seen[dir] && strings.HasSuffix(file, ".go") && !strings.HasSuffix(file, "_test.go") {
+				seen[dir] = true
+				stdlibPackages[path.Base(dir)] = append(stdlibPackages[path.Base(dir)], dir)
+			}
+			return nil
+		})
+	})
+
+	best, tie := "", false
+	for _, p := range stdlibPackages[name] {
+		switch depth, bestDepth := strings.Count(p, "/"), strings.Count(best, "/"); {
+		case best == "" || depth < bestDepth:
+			best, tie = p, false
+		case depth == bestDepth:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
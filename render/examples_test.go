@@ -0,0 +1,136 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleReadme = "# Title\n\n" +
+	"```go example=Provide\nimport \"fmt\"\n\nfmt.Println(pkg.Provide())\n```\n\n" +
+	"```output\nprovided\n```\n\n" +
+	"A function:\n\n```go\nfunc f() {}\n```\n\n" +
+	"```go\nx := 1\n_ = x\n```\n\n" +
+	"```sh\nls\n```\n"
+
+func TestExamples(t *testing.T) {
+	out, err := Examples([]byte(exampleReadme), ExampleOptions{
+		Package:    "pkg",
+		ImportPath: "example.com/pkg",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `package pkg_test
+
+import (
+	"example.com/pkg"
+	"fmt"
+)
+
+func ExampleProvide() {
+	fmt.Println(pkg.Provide())
+	// Output:
+	// provided
+}
+
+func Example_readme() {
+	x := 1
+	_ = x
+}
+`, string(out))
+}
+
+func TestExamples_StandardImports(t *testing.T) {
+	md := "```go example=Provide\nfmt.Println(strings.ToUpper(pkg.Provide()))\n```\n\n" +
+		"```output\nPROVIDED\n```\n\n" +
+		"```go\nvar b bytes.Buffer\nb.WriteString(filepath.Join(\"a\", \"b\"))\nfmt.Println(b.String())\n```\n"
+	out, err := Examples([]byte(md), ExampleOptions{
+		Package:    "pkg",
+		ImportPath: "example.com/pkg",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `import (
+	"bytes"
+	"example.com/pkg"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+`)
+
+	// The examples type check against the package they're for
+	fset := token.NewFileSet()
+	pkgFile, err := parser.ParseFile(fset, "pkg.go", "package pkg\n\nfunc Provide() string { return \"provided\" }\n", 0)
+	require.NoError(t, err)
+	pkg, err := new(types.Config).Check("example.com/pkg", fset, []*ast.File{pkgFile}, nil)
+	require.NoError(t, err)
+	std := importer.ForCompiler(fset, "source", nil)
+	conf := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if path == "example.com/pkg" {
+			return pkg, nil
+		}
+		return std.Import(path)
+	})}
+	exampleFile, err := parser.ParseFile(fset, "example_readme_test.go", out, 0)
+	require.NoError(t, err)
+	_, err = conf.Check("example.com/pkg_test", fset, []*ast.File{exampleFile}, nil)
+	assert.NoError(t, err)
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func TestExamples_None(t *testing.T) {
+	out, err := Examples([]byte("# Title\n\n```go\nfunc f() {}\n```\n\n```sh\nls\n```\n"), ExampleOptions{Package: "pkg"})
+	require.NoError(t, err)
+	assert.Nil(t, out)
+}
+
+func TestStdlibPackage(t *testing.T) {
+	assert.Equal(t, "fmt", stdlibPackage("fmt"))
+	assert.Equal(t, "net/http", stdlibPackage("http"))
+	assert.Equal(t, "", stdlibPackage("rand"))
+	assert.Equal(t, "", stdlibPackage("nonexistent"))
+}
+
+func TestExamples_NoReference(t *testing.T) {
+	out, err := Examples([]byte("```go\nprintln()\n```\n"), ExampleOptions{
+		Package:    "pkg",
+		ImportPath: "example.com/pkg",
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "import")
+}
+
+func TestExamples_InvalidTagged(t *testing.T) {
+	_, err := Examples([]byte("```go example=Bad\nfunc f() {}\n```\n"), ExampleOptions{Package: "pkg"})
+	assert.EqualError(t, err, "example ExampleBad is not a list of statements")
+}
+
+func TestExamples_Duplicate(t *testing.T) {
+	md := "```go example=Dup\nprintln()\n```\n\n```go example=Dup\nprintln()\n```\n"
+	_, err := Examples([]byte(md), ExampleOptions{Package: "pkg"})
+	assert.EqualError(t, err, "duplicate example ExampleDup")
+}
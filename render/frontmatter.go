@@ -0,0 +1,112 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// FrontMatter holds the keys of a document's front matter that override
+// options for that document.
+type FrontMatter struct {
+	// Package is the name of the package being documented.
+	Package string `yaml:"package" toml:"package"`
+	// Title replaces the leading heading of the document as the title used in
+	// the package synopsis.
+	Title string `yaml:"title" toml:"title"`
+	// Synopsis replaces the first sentence of the package documentation.
+	Synopsis string `yaml:"synopsis" toml:"synopsis"`
+	// Version is what {{.Version}} expands to in templates, unless -version
+	// is set.
+	Version string `yaml:"version" toml:"version"`
+	// ExcludeSections lists headings of sections to remove.
+	ExcludeSections []string `yaml:"exclude-sections" toml:"exclude-sections"`
+	// Badges enables or disables output for badges, if set.
+	Badges *bool `yaml:"badges" toml:"badges"`
+	// DemoteHeadings, if set, is the number of levels the headings of the
+	// document are moved down by when it's rendered along with others.
+	DemoteHeadings *Demotion `yaml:"demote-headings" toml:"demote-headings"`
+}
+
+var (
+	yamlDelim = []byte("---")
+	yamlEnd   = []byte("...")
+	tomlDelim = []byte("+++")
+)
+
+// SplitFrontMatter detects YAML (delimited by ---) or TOML (delimited by +++)
+// front matter at the very start of a markdown document. It returns the parsed
+// front matter and the rest of the document. Documents without front matter
+// are returned untouched, including those that start with a --- thematic
+// break, which are told apart by holding valid YAML that isn't a mapping.
+func SplitFrontMatter(input []byte) (FrontMatter, []byte, error) {
+	var fm FrontMatter
+
+	first, rest := splitLine(input)
+	isTOML := bytes.Equal(first, tomlDelim)
+	if !isTOML && !bytes.Equal(first, yamlDelim) {
+		return fm, input, nil
+	}
+
+	var matter []byte
+	for body := rest; len(body) > 0; {
+		line, next := splitLine(body)
+		if bytes.Equal(line, first) || (!isTOML && bytes.Equal(line, yamlEnd)) {
+			if !isTOML && isMarkdown(matter) {
+				return fm, input, nil
+			}
+			var err error
+			if isTOML {
+				err = toml.Unmarshal(matter, &fm)
+			} else {
+				err = yaml.Unmarshal(matter, &fm)
+			}
+			if err != nil {
+				return fm, input, fmt.Errorf("invalid front matter: %v", err)
+			}
+			return fm, next, nil
+		}
+		matter = append(matter, body[:len(body)-len(next)]...)
+		body = next
+	}
+
+	// No closing delimiter, so this was never front matter
+	return fm, input, nil
+}
+
+// isMarkdown returns true if matter is markdown between two thematic breaks:
+// valid YAML, but not a mapping. Invalid YAML is left to be reported.
+func isMarkdown(matter []byte) bool {
+	var v interface{}
+	if err := yaml.Unmarshal(matter, &v); err != nil {
+		return false
+	}
+	_, ok := v.(map[interface{}]interface{})
+	return !ok
+}
+
+// splitLine returns the first line of b, without its line ending, and
+// everything after it.
+func splitLine(b []byte) (line, rest []byte) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return bytes.TrimRight(b, "\r"), nil
+	}
+	return bytes.TrimRight(b[:i], "\r"), b[i+1:]
+}
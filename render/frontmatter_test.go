@@ -0,0 +1,133 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"testing"
+
+	"github.com/russross/blackfriday"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFrontMatter_YAML(t *testing.T) {
+	input := []byte(`---
+package: fx
+title: Fx
+badges: true
+exclude-sections:
+  - Installation
+  - License
+---
+# Heading
+
+Text.
+`)
+	fm, rest, err := SplitFrontMatter(input)
+	require.NoError(t, err)
+
+	assert.Equal(t, "fx", fm.Package)
+	assert.Equal(t, "Fx", fm.Title)
+	require.NotNil(t, fm.Badges)
+	assert.True(t, *fm.Badges)
+	assert.Equal(t, []string{"Installation", "License"}, fm.ExcludeSections)
+	assert.Equal(t, "# Heading\n\nText.\n", string(rest))
+}
+
+func TestSplitFrontMatter_TOML(t *testing.T) {
+	input := []byte("+++\r\nsynopsis = \"does things.\"\r\nexclude-sections = [\"License\"]\r\n+++\r\nText.\r\n")
+	fm, rest, err := SplitFrontMatter(input)
+	require.NoError(t, err)
+
+	assert.Equal(t, "does things.", fm.Synopsis)
+	assert.Equal(t, []string{"License"}, fm.ExcludeSections)
+	assert.Nil(t, fm.Badges)
+	assert.Equal(t, "Text.\r\n", string(rest))
+}
+
+func TestSplitFrontMatter_DemoteHeadings(t *testing.T) {
+	for input, want := range map[string]Demotion{
+		"---\ndemote-headings: true\n---\n":   1,
+		"---\ndemote-headings: 2\n---\n":      2,
+		"+++\ndemote-headings = false\n+++\n": 0,
+		"+++\ndemote-headings = 3\n+++\n":     3,
+	} {
+		fm, _, err := SplitFrontMatter([]byte(input))
+		require.NoError(t, err, input)
+		require.NotNil(t, fm.DemoteHeadings, input)
+		assert.Equal(t, want, *fm.DemoteHeadings, input)
+	}
+}
+
+func TestSplitFrontMatter_None(t *testing.T) {
+	for _, input := range []string{
+		"# Heading\n\nText.\n",
+		"---\nnot: closed\n",
+		"---\n\n# Heading\n\nText.\n\n---\n\nMore.\n",
+		"",
+	} {
+		fm, rest, err := SplitFrontMatter([]byte(input))
+		require.NoError(t, err)
+		assert.Equal(t, FrontMatter{}, fm)
+		assert.Equal(t, input, string(rest))
+	}
+}
+
+func TestSplitFrontMatter_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"---\nbadges: [\n---\n",
+		"---\nbadges: maybe\n---\n",
+	} {
+		_, _, err := SplitFrontMatter([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestTitleAndSynopsis_BlockScalars(t *testing.T) {
+	for _, input := range []string{
+		"---\nsynopsis: >\n  does\n  things.\ntitle: |\n  The\n  Thing\n---\nText.\n",
+		"---\nsynopsis: |\n  does\n  things.\ntitle: >\n  The\n  Thing\n---\nText.\n",
+	} {
+		fm, rest, err := SplitFrontMatter([]byte(input))
+		require.NoError(t, err)
+		out, err := Generate(rest, Options{Package: "pkg", Synopsis: fm.Synopsis})
+		require.NoError(t, err, input)
+		assert.Equal(t, "// Package pkg does things.\n//\n// Text.\npackage pkg\n", string(out))
+		out, err = Generate(rest, Options{Package: "pkg", Title: fm.Title})
+		require.NoError(t, err, input)
+		assert.Equal(t, "// Package pkg is the The Thing.\n//\n// Text.\npackage pkg\n", string(out))
+	}
+}
+
+func TestTitleAndSynopsis(t *testing.T) {
+	md := []byte("# Heading\n\nText.\n")
+
+	tests := []struct {
+		opts     Options
+		expected string
+	}{
+		{Options{Package: "pkg"}, "// Package pkg is the Heading.\n//\n// Text.\n"},
+		{Options{Package: "pkg", Title: "Thing"}, "// Package pkg is the Thing.\n//\n// Text.\n"},
+		{Options{Package: "pkg", Synopsis: "does things."}, "// Package pkg does things.\n//\n// Text.\n"},
+		{Options{Package: "pkg", Synopsis: "Package pkg does things."}, "// Package pkg does things.\n//\n// Text.\n"},
+	}
+	for _, tt := range tests {
+		output := blackfriday.Markdown(md, GodocWithOptions(tt.opts), blackfriday.Options{
+			Extensions: GodocExtensions,
+		})
+		assert.Contains(t, string(output), tt.expected)
+		assert.NotContains(t, string(output), "Heading\n//\n// Text", "leading heading should be replaced")
+	}
+}
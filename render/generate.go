@@ -0,0 +1,196 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/doc/comment"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// reformatWidth is the width paragraphs are wrapped to by Options.Reformat,
+// not counting the comment markers.
+const reformatWidth = 77
+
+// Generate runs the rendering pipeline on a markdown document, without front
+// matter: the document is parsed, its sections filtered and the result
+// rendered into the source of a doc.go file, below the license header. The
+// source is tidied up and formatted like gofmt does, so that it's stable under
+// gofmt -w.
+func Generate(input []byte, opts Options) ([]byte, error) {
+	return GenerateDocuments([]Document{{Markdown: input}}, opts)
+}
+
+// GenerateDocuments is like Generate, but renders several documents one after
+// the other into a single package comment.
+func GenerateDocuments(docs []Document, opts Options) ([]byte, error) {
+	ast := mergeDocuments(docs, opts)
+	opts.Sections.Filter(ast)
+
+	var buff bytes.Buffer
+	writeLicense(&buff, opts.License)
+	buff.Write(GodocWithOptions(opts).Render(ast))
+
+	src := tidyComments(buff.Bytes())
+	if opts.Reformat {
+		var err error
+		if src, err = reformat(src); err != nil {
+			return nil, err
+		}
+	}
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid source: %v", err)
+	}
+	lintSynopsis(out, opts)
+	if opts.GeneratedFrom != "" {
+		out = append(generatedHeader(opts.GeneratedFrom, out), out...)
+	}
+	return out, nil
+}
+
+// parse parses a markdown document with the extensions godoc output
+// supports.
+func parse(input []byte) *blackfriday.Node {
+	return blackfriday.Parse(braceFences(input), blackfriday.Options{
+		Extensions: GodocExtensions,
+	})
+}
+
+// braceFences rewrites opening code fences with more than one word of info,
+// such as "```go example=Name", to the "```{go example=Name}" form. The
+// vendored parser doesn't treat the former as a fence at all, but keeps the
+// whole info string of the latter.
+func braceFences(input []byte) []byte {
+	lines := bytes.SplitAfter(input, nl)
+	var open string // the opening fence of the current block
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(string(line))
+		fence := fencePrefix(trimmed)
+		switch {
+		case fence == "":
+		case open == "":
+			open = fence
+			info := strings.TrimSpace(trimmed[len(fence):])
+			if strings.ContainsAny(info, " \t") && !strings.HasPrefix(info, "{") {
+				indent := line[:bytes.IndexAny(line, "`~")]
+				lines[i] = []byte(fmt.Sprintf("%s%s{%s}\n", indent, fence, info))
+			}
+		case strings.HasPrefix(fence, open) && fence == trimmed:
+			open = ""
+		}
+	}
+	return bytes.Join(lines, nil)
+}
+
+// fencePrefix returns the run of three or more backticks or tildes that line
+// starts with, if any.
+func fencePrefix(line string) string {
+	if !strings.HasPrefix(line, "```") && !strings.HasPrefix(line, "~~~") {
+		return ""
+	}
+	end := 0
+	for end < len(line) && line[end] == line[0] {
+		end++
+	}
+	return line[:end]
+}
+
+// tidyComments removes the runs of blank comment lines left behind by the
+// renderer, along with blank comment lines at the start or end of a comment
+// and trailing whitespace.
+func tidyComments(src []byte) []byte {
+	lines := bytes.Split(src, nl)
+	for i := range lines {
+		lines[i] = bytes.TrimRight(lines[i], " \t")
+	}
+
+	var out [][]byte
+	for i, line := range lines {
+		if bytes.Equal(line, slashslash) {
+			prev := []byte(nil)
+			if len(out) > 0 {
+				prev = out[len(out)-1]
+			}
+			// Look past any more blank lines for the end of the comment
+			next := []byte(nil)
+			for _, l := range lines[i+1:] {
+				if !bytes.Equal(l, slashslash) {
+					next = l
+					break
+				}
+			}
+			if !bytes.HasPrefix(prev, slashslash) || bytes.Equal(prev, slashslash) || !bytes.HasPrefix(next, slashslash) {
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	return bytes.Join(out, nl)
+}
+
+// reformat rewraps the package documentation in src with go/doc/comment's
+// text printer.
+func reformat(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid source: %v", err)
+	}
+	if f.Doc == nil {
+		return src, nil
+	}
+
+	var p comment.Parser
+	pr := comment.Printer{
+		TextPrefix:     "// ",
+		TextCodePrefix: "//\t",
+		TextWidth:      reformatWidth,
+	}
+	doc := pr.Text(p.Parse(f.Doc.Text()))
+
+	start := fset.Position(f.Doc.Pos()).Offset
+	end := fset.Position(f.Doc.End()).Offset
+	var out bytes.Buffer
+	out.Write(src[:start])
+	out.Write(bytes.TrimSuffix(doc, nl))
+	out.Write(src[end:])
+	return out.Bytes(), nil
+}
+
+// writeLicense writes the license text as a line comment, separated from the
+// package documentation by a blank line.
+func writeLicense(out *bytes.Buffer, license []byte) {
+	if len(license) == 0 {
+		return
+	}
+	s := bufio.NewScanner(bytes.NewBuffer(license))
+	for s.Scan() {
+		out.Write(slashslash)
+		if len(s.Bytes()) > 0 {
+			out.Write(space)
+			out.Write(s.Bytes())
+		}
+		out.Write(nl)
+	}
+	out.Write(nl)
+}
@@ -0,0 +1,166 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"fmt"
+	"go/format"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	md := []byte("# Title\n\nIntro.\n\n## License\n\nMIT.\n")
+
+	out, err := Generate(md, Options{
+		Package:  "pkg",
+		License:  []byte("Copyright 2016\n\nAll rights reserved.\n"),
+		Sections: SectionFilter{Exclude: []*regexp.Regexp{regexp.MustCompile("License")}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		"// Copyright 2016\n//\n// All rights reserved.\n\n"+
+			"// Package pkg is the Title.\n//\n// Intro.\npackage pkg\n",
+		string(out),
+	)
+}
+
+func TestGenerate_NoLicense(t *testing.T) {
+	out, err := Generate([]byte("Text.\n"), Options{Package: "pkg"})
+	require.NoError(t, err)
+	assert.Equal(t, "// Package pkg is the Text.\npackage pkg\n", string(out))
+}
+
+func TestGenerate_Gofmt(t *testing.T) {
+	md := []byte("# Title\n\nIntro.\n\n## Usage\n\nRun it:\n\n```go\nfunc main() {\n    run()\n}\n```\n")
+
+	out, err := Generate(md, Options{Package: "pkg"})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"// Package pkg is the Title.\n//\n// Intro.\n//\n// # Usage\n//\n// Run it:\n//\n"+
+			"//\tfunc main() {\n//\t    run()\n//\t}\npackage pkg\n",
+		string(out),
+	)
+
+	formatted, err := format.Source(out)
+	require.NoError(t, err)
+	assert.Equal(t, string(out), string(formatted), "output should be stable under gofmt")
+}
+
+func TestGenerate_InvalidPackage(t *testing.T) {
+	_, err := Generate([]byte("Text.\n"), Options{Package: "not a package"})
+	assert.Error(t, err)
+}
+
+func TestGenerate_Reformat(t *testing.T) {
+	md := []byte("# Title\n\nA `short` line.\nAnother line,\nthat are joined up.\n")
+
+	out, err := Generate(md, Options{Package: "pkg", Reformat: true})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"// Package pkg is the Title.\n//\n// A short line. Another line, that are joined up.\npackage pkg\n",
+		string(out),
+	)
+}
+
+func TestGenerate_FormatCode(t *testing.T) {
+	md := []byte("Run it:\n\n```go\nx:=1\nif x>0 {\n    run( x )\n}\n```\n\n```sh\nx  =  1\n```\n")
+
+	out, err := Generate(md, Options{Package: "pkg", FormatCode: true})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"// Package pkg is the Run it:\n//\n"+
+			"//\tx := 1\n//\tif x > 0 {\n//\t\trun(x)\n//\t}\n//\n"+
+			"//\tx  =  1\npackage pkg\n",
+		string(out),
+	)
+}
+
+func TestGenerate_FormatCodeInvalid(t *testing.T) {
+	md := []byte("Run it:\n\n```golang\nfunc main() {\n    run(\n```\n")
+
+	var warnings []string
+	out, err := Generate(md, Options{
+		Package:    "pkg",
+		FormatCode: true,
+		Warnf: func(format string, args ...interface{}) {
+			// Leave out what the synopsis lint says about the input
+			if warning := fmt.Sprintf(format, args...); !strings.HasPrefix(warning, "synopsis") {
+				warnings = append(warnings, warning)
+			}
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "//\tfunc main() {\n//\t    run(\n")
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], `could not gofmt code block starting "func main() {"`)
+}
+
+func TestGenerate_CodeLanguages(t *testing.T) {
+	md := []byte("Intro.\n\n" +
+		"```mermaid\ngraph TD;\n```\n\n" +
+		"```console\n$ go get\n\tdone\n```\n\n" +
+		"```yaml\na:\n\tb: 1\n  \tc: 2\n```\n\n" +
+		"```go\nfunc f() {\n\treturn\n}\n```\n")
+
+	out, err := Generate(md, Options{
+		Package:          "pkg",
+		SkipLanguages:    []string{"Mermaid"},
+		LanguageCaptions: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"// Package pkg is the Intro.\n//\n"+
+			"// Language: console\n//\n//\t$ go get\n//\t\tdone\n//\n"+
+			"// Language: yaml\n//\n//\ta:\n//\t    b: 1\n//\t    c: 2\n//\n"+
+			"//\tfunc f() {\n//\t\treturn\n//\t}\npackage pkg\n",
+		string(out),
+	)
+}
+
+func TestExpandTabs(t *testing.T) {
+	tests := map[string]string{
+		"no tabs":    "no tabs",
+		"\tone":      "    one",
+		"  \ttwo":    "    two",
+		"\t \tthree": "        three",
+		"inner\ttab": "inner\ttab",
+		"\t":         "    ",
+	}
+	for line, want := range tests {
+		assert.Equal(t, want, string(expandTabs([]byte(line))), "%q", line)
+	}
+}
+
+func TestBraceFences(t *testing.T) {
+	md := "```go example=Name\n```not a fence\n```\n\n  ~~~~ sh  -x\n~~~~\n```go\n```\n"
+	assert.Equal(t,
+		"```{go example=Name}\n```not a fence\n```\n\n  ~~~~{sh  -x}\n~~~~\n```go\n```\n",
+		string(braceFences([]byte(md))),
+	)
+}
+
+func TestTidyComments(t *testing.T) {
+	src := "// License\n\n// Package pkg is the Title.\n//\n//\n// Text.   \n//\n//\n//\npackage pkg\n"
+	assert.Equal(t,
+		"// License\n\n// Package pkg is the Title.\n//\n// Text.\npackage pkg\n",
+		string(tidyComments([]byte(src))),
+	)
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// generatedPrefix starts the header of generated files. The whole line
+	// follows the convention that tools recognize generated files by.
+	generatedPrefix = "// Code generated by md-to-godoc"
+	// checksumPrefix starts the line of the header that records what the
+	// rest of the file was generated as.
+	checksumPrefix = "// md-to-godoc checksum: "
+)
+
+// generatedHeader returns the header marking body as generated from source.
+// It's followed by a blank line, so that it doesn't become part of the
+// license or package comment.
+func generatedHeader(source string, body []byte) []byte {
+	return []byte(fmt.Sprintf("%s from %s; DO NOT EDIT.\n%s%s\n\n", generatedPrefix, source, checksumPrefix, checksum(body)))
+}
+
+func checksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:8])
+}
+
+// HandEdited reports whether src starts with the header written by Generate
+// with Options.GeneratedFrom, and if so, whether the rest of it has been
+// changed since.
+func HandEdited(src []byte) (marked, edited bool) {
+	if !bytes.HasPrefix(src, []byte(generatedPrefix)) {
+		return false, false
+	}
+	_, rest := splitLine(src)
+	line, body := splitLine(rest)
+	if !bytes.HasPrefix(line, []byte(checksumPrefix)) {
+		return true, true
+	}
+	sum := string(bytes.TrimPrefix(line, []byte(checksumPrefix)))
+	body = bytes.TrimPrefix(body, nl)
+	return true, sum != checksum(body)
+}
@@ -0,0 +1,52 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_GeneratedFrom(t *testing.T) {
+	out, err := Generate([]byte("Text.\n"), Options{
+		Package:       "pkg",
+		License:       []byte("MIT"),
+		GeneratedFrom: "README.md",
+	})
+	require.NoError(t, err)
+
+	lines := bytes.SplitN(out, nl, 4)
+	assert.Equal(t, "// Code generated by md-to-godoc from README.md; DO NOT EDIT.", string(lines[0]))
+	assert.Regexp(t, "^// md-to-godoc checksum: [0-9a-f]{16}$", string(lines[1]))
+	assert.Equal(t, "", string(lines[2]))
+	assert.Equal(t, "// MIT\n\n// Package pkg is the Text.\npackage pkg\n", string(lines[3]))
+
+	marked, edited := HandEdited(out)
+	assert.True(t, marked)
+	assert.False(t, edited)
+
+	marked, edited = HandEdited(bytes.Replace(out, []byte("Text."), []byte("Edited."), 1))
+	assert.True(t, marked)
+	assert.True(t, edited)
+}
+
+func TestHandEdited_Unmarked(t *testing.T) {
+	marked, edited := HandEdited([]byte("// Package pkg is the Text.\npackage pkg\n"))
+	assert.False(t, marked)
+	assert.False(t, edited)
+}
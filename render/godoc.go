@@ -19,11 +19,17 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"go/format"
 	"io"
 	"log"
+	"net/url"
 	"os"
+	"path"
+	"strings"
+	"unicode"
 
 	"github.com/russross/blackfriday"
+	"github.com/shurcooL/sanitized_anchor_name"
 )
 
 // GodocExtensions are the default markdown extensions for blackfriday
@@ -38,34 +44,196 @@ var (
 	slashslash = []byte("//")
 )
 
+// Options configures a GodocRenderer.
+type Options struct {
+	// Package is the name of the package being documented.
+	Package string
+	// Badges enables output for badges (links with images).
+	Badges bool
+	// Links controls how relative link destinations are rewritten.
+	Links Links
+	// Anchors controls how links to headings within the document are
+	// rendered. Defaults to AnchorDrop.
+	Anchors AnchorStyle
+	// Title replaces the leading heading of the document in the package
+	// synopsis, "Package pkg is the Title."
+	Title string
+	// Synopsis replaces the first sentence of the package documentation, and
+	// with it the leading heading of the document. It's prefixed with
+	// "Package pkg " unless it already starts with "Package ".
+	Synopsis string
+	// SynopsisFrom says where the synopsis comes from when Synopsis is empty.
+	// Defaults to SynopsisHeading.
+	SynopsisFrom SynopsisSource
+	// SynopsisLimit is the length above which Generate reports the synopsis
+	// as too long through Warnf. Defaults to DefaultSynopsisLimit.
+	SynopsisLimit int
+
+	// Sections selects the sections of the document to render. It's applied
+	// by Generate, before rendering.
+	Sections SectionFilter
+	// License is written as a comment above the package documentation by
+	// Generate.
+	License []byte
+	// DemoteHeadings makes GenerateDocuments demote the headings of every
+	// document but the first by this many levels, usually one, so that they
+	// nest under its title. Document.DemoteHeadings overrides it for a
+	// single document.
+	DemoteHeadings Demotion
+	// GeneratedFrom, if set, makes Generate start the file with a header
+	// marking it as generated from the named source, along with a checksum
+	// that HandEdited uses to tell whether it was edited afterwards.
+	GeneratedFrom string
+	// Reformat makes Generate rewrap the package documentation with
+	// go/doc/comment's printer.
+	Reformat bool
+	// FormatCode runs gofmt on fenced code blocks in go or golang. Snippets
+	// that can't be formatted are left alone and reported through Warnf.
+	FormatCode bool
+	// SkipLanguages lists the languages of code blocks to leave out, such as
+	// mermaid diagrams that mean nothing in godoc.
+	SkipLanguages []string
+	// LanguageCaptions puts a "Language: x" line above code blocks that aren't
+	// Go but say what they are.
+	LanguageCaptions bool
+	// TOC inserts a list of the document's sections after the synopsis, or
+	// in place of a <!-- toc --> marker. A hand-written contents list right
+	// after the marker, or up to a <!-- tocstop --> marker, is replaced.
+	TOC bool
+
+	// Warnf, if set, is called with problems found in the document that
+	// don't stop it from being rendered.
+	Warnf func(format string, args ...interface{})
+}
+
+// AnchorStyle controls how links to headings within the document, such as
+// [see Usage](#usage), are rendered.
+type AnchorStyle string
+
+const (
+	// AnchorDrop keeps the link text and drops the destination.
+	AnchorDrop AnchorStyle = "drop"
+	// AnchorHeading replaces the link text with the text of the heading.
+	AnchorHeading AnchorStyle = "heading"
+	// AnchorGodoc points the link at the heading's anchor on pkg.go.dev, e.g.
+	// #hdr-Usage.
+	AnchorGodoc AnchorStyle = "godoc"
+)
+
+// Links describes where the documented package lives, so that relative link
+// destinations can be rewritten into something meaningful in godoc.
+type Links struct {
+	// ImportPath is the import path of the package being documented. Links to
+	// the README of another package are rewritten into a doc link to that
+	// package's import path.
+	ImportPath string
+	// Dir is the directory of the package, relative to the repository root.
+	Dir string
+	// RepoURL is the base URL of the repository, e.g.
+	// https://github.com/sectioneight/md-to-godoc. Links to any other file
+	// are rewritten into an absolute URL on the repository.
+	RepoURL string
+	// Branch is the branch that repository URLs point at.
+	Branch string
+}
+
 // Godoc returns a blackfriday renderer for doc.go style package documentation.
 func Godoc(pkg string, badges bool) blackfriday.Renderer {
+	return GodocWithOptions(Options{
+		Package: pkg,
+		Badges:  badges,
+	})
+}
+
+// GodocWithOptions returns a blackfriday renderer for doc.go style package
+// documentation, configured by opts.
+func GodocWithOptions(opts Options) blackfriday.Renderer {
 	return &GodocRenderer{
-		pkg:     pkg,
-		noBadge: !badges,
+		pkg:        opts.Package,
+		noBadge:    !opts.Badges,
+		links:      opts.Links,
+		anchors:    opts.Anchors,
+		title:      oneLine(opts.Title),
+		synopsis:   oneLine(opts.Synopsis),
+		fromPara:   opts.SynopsisFrom == SynopsisParagraph,
+		formatCode: opts.FormatCode,
+		skipLangs:  opts.SkipLanguages,
+		captions:   opts.LanguageCaptions,
+		toc:        opts.TOC,
+		warnf:      opts.Warnf,
 	}
 }
 
+// oneLine collapses the whitespace in s, such as the line breaks of a YAML
+// block scalar, into single spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
 // GodocRenderer implements the blackfriday.Render interface for doc.go style
 // package documentation
 type GodocRenderer struct {
-	pkg     string
-	noBadge bool
+	pkg        string
+	noBadge    bool
+	links      Links
+	anchors    AnchorStyle
+	title      string
+	synopsis   string
+	fromPara   bool
+	formatCode bool
+	skipLangs  []string
+	captions   bool
+	toc        bool
+	warnf      func(format string, args ...interface{})
+
+	// titleNode is the leading heading, when it's replaced by title or synopsis
+	titleNode *blackfriday.Node
+	// synopsisNode is the paragraph the synopsis was taken from, if any
+	synopsisNode *blackfriday.Node
+	// headings maps anchor names to the text of the heading they refer to
+	headings map[string][]byte
+	// contents are the headings listed in the table of contents
+	contents []*blackfriday.Node
+	// tocMarker is the <!-- toc --> marker the table of contents replaces
+	tocMarker *blackfriday.Node
+	// tocAfter is the node the table of contents follows, if there's no
+	// marker and it doesn't follow the document header
+	tocAfter *blackfriday.Node
 
 	pkgHeaderWritten bool
 	lastOutputLen    int
 	imageInLink      bool
 	inLink           bool
 	newline          bool
+	skipping         bool
 }
 
 // Render walks the specified (sub)tree and returns a godoc document.
 func (g *GodocRenderer) Render(ast *blackfriday.Node) []byte {
 	var buff bytes.Buffer
+	g.headings = collectHeadings(ast)
+	if g.fromPara && g.synopsis == "" {
+		g.paragraphSynopsis(ast)
+	}
+	if g.title != "" || g.synopsis != "" {
+		if first := ast.FirstChild; ast.Type == blackfriday.Document && first != nil && first.Type == blackfriday.Header {
+			g.titleNode = first
+		}
+	}
+	if g.toc {
+		g.tableOfContents(ast)
+	}
 	g.DocumentHeader(&buff)
+	if g.toc && g.tocMarker == nil && g.tocAfter == nil {
+		g.writeTOC(&buff)
+	}
 
 	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
-		return g.RenderNode(&buff, node, entering)
+		status := g.RenderNode(&buff, node, entering)
+		if node == g.tocAfter && !entering {
+			g.writeTOC(&buff)
+		}
+		return status
 	})
 
 	g.DocumentFooter(&buff)
@@ -89,6 +257,14 @@ func (g *GodocRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering
 		log.Printf("Type: %+v Val: |%+v|, /%+v/ %v\n", node.Type, string(node.Literal), node, entering)
 	}
 
+	if g.skipping && node.Type != blackfriday.HTMLBlock && node.Type != blackfriday.HTMLSpan {
+		return blackfriday.GoToNext
+	}
+	if node == g.titleNode || node == g.synopsisNode {
+		// Already written by DocumentHeader
+		return blackfriday.SkipChildren
+	}
+
 	switch node.Type {
 	case blackfriday.Text:
 		if g.inLink && g.imageInLink && g.noBadge {
@@ -102,17 +278,21 @@ func (g *GodocRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering
 			if debug {
 				fmt.Printf("Line %d, val |%v|\n", idx, string(line))
 			}
-			// Trim off trailing space for OCD
-			if idx > 0 && len(line) > 0 && string(line[len(line)-1]) == " " {
+			// Trim off trailing space for OCD, unless more follows on the line
+			if idx < len(lines)-1 && len(line) > 0 && string(line[len(line)-1]) == " " {
 				if debug {
 					fmt.Println("Trimming trailing space" + string(line))
 				}
 				line = line[0 : len(line)-1]
 			}
-			g.out(w, line)
-			if len(lines) > 1 {
+			// Line breaks go between lines, so that inline code or links
+			// either side of one stay apart from the text
+			if idx > 0 {
 				g.cr(w)
 			}
+			if len(line) > 0 {
+				g.out(w, line)
+			}
 		}
 
 	case blackfriday.Softbreak:
@@ -158,14 +338,26 @@ func (g *GodocRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering
 			g.imageInLink = false
 			return blackfriday.GoToNext
 		}
+		heading, isAnchor := g.anchorHeading(node.LinkData.Destination)
 		if entering {
+			if isAnchor && g.anchors == AnchorHeading {
+				g.out(w, heading)
+				g.inLink = false
+				return blackfriday.SkipChildren
+			}
 			if len(node.LinkData.Title) > 0 {
 				g.out(w, node.LinkData.Title)
 			}
 		} else {
-			dest := node.LinkData.Destination
+			dest := g.rewriteLink(node.LinkData.Destination)
 			// Reset this for badge detection
 			g.imageInLink = false
+			if isAnchor {
+				if g.anchors != AnchorGodoc {
+					break
+				}
+				dest = g.godocAnchor(heading)
+			}
 			g.out(w, []byte(" ("))
 			g.out(w, dest)
 			g.out(w, []byte(")"))
@@ -201,6 +393,16 @@ func (g *GodocRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering
 		// Sadly, no inline code support or emphasis
 		g.out(w, node.Literal)
 
+	case blackfriday.HTMLBlock:
+		if node == g.tocMarker {
+			g.writeTOC(w)
+			break
+		}
+		// HTML means nothing to godoc, but comments may hold directives for us
+		g.directive(w, node.Literal, false)
+	case blackfriday.HTMLSpan:
+		g.directive(w, node.Literal, true)
+
 	case blackfriday.Table:
 		// unsupported, do nothing
 	case blackfriday.TableCell, blackfriday.TableRow, blackfriday.TableBody, blackfriday.TableHead:
@@ -213,6 +415,272 @@ func (g *GodocRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering
 	return blackfriday.GoToNext
 }
 
+// collectHeadings maps the anchor name of every heading in the document to the
+// heading's text, the same way blackfriday generates header IDs.
+func collectHeadings(ast *blackfriday.Node) map[string][]byte {
+	headings := make(map[string][]byte)
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if node.Type != blackfriday.Header || !entering {
+			return blackfriday.GoToNext
+		}
+		text := headingText(node)
+		headings[sanitized_anchor_name.Create(string(text))] = text
+		if node.HeaderID != "" {
+			headings[node.HeaderID] = text
+		}
+		return blackfriday.SkipChildren
+	})
+	return headings
+}
+
+// tableOfContents collects the headings to list in the table of contents,
+// leaving out the leading one that makes the synopsis and any skipped with
+// godoc:skip, and works out where to put it. A hand-written contents list
+// after a <!-- toc --> marker is removed from the document.
+func (g *GodocRenderer) tableOfContents(ast *blackfriday.Node) {
+	skipping := false
+	for node := ast.FirstChild; node != nil; node = node.Next {
+		switch node.Type {
+		case blackfriday.HTMLBlock:
+			switch commentName(node.Literal) {
+			case "godoc:skip":
+				skipping = true
+			case "godoc:end":
+				skipping = false
+			case "toc":
+				if g.tocMarker == nil && !skipping {
+					g.tocMarker = node
+					removeContents(node)
+				}
+			}
+		case blackfriday.Header:
+			if node != ast.FirstChild && !skipping {
+				g.contents = append(g.contents, node)
+			}
+		}
+	}
+	if g.tocMarker != nil || g.synopsis != "" || g.title != "" {
+		return
+	}
+	// Otherwise the synopsis is the first heading or paragraph
+	for node := ast.FirstChild; node != nil; node = node.Next {
+		if node.Type == blackfriday.Header || node.Type == blackfriday.Paragraph {
+			g.tocAfter = node
+			return
+		}
+	}
+}
+
+// removeContents removes the hand-written contents list following a
+// <!-- toc --> marker: everything up to a <!-- tocstop --> marker if there is
+// one, or else a list right after it.
+func removeContents(marker *blackfriday.Node) {
+	for node := marker.Next; node != nil; node = node.Next {
+		if node.Type == blackfriday.HTMLBlock && commentName(node.Literal) == "tocstop" {
+			for marker.Next != node {
+				marker.Next.Unlink()
+			}
+			return
+		}
+	}
+	if next := marker.Next; next != nil && next.Type == blackfriday.List {
+		next.Unlink()
+	}
+}
+
+// commentName returns the first word of the HTML comment that html starts
+// with, or an empty string if it doesn't start with one.
+func commentName(html []byte) string {
+	html = bytes.TrimSpace(html)
+	if !bytes.HasPrefix(html, []byte("<!--")) {
+		return ""
+	}
+	body := html[len("<!--"):]
+	if end := bytes.Index(body, []byte("-->")); end >= 0 {
+		body = body[:end]
+	}
+	fields := bytes.Fields(body)
+	if len(fields) == 0 {
+		return ""
+	}
+	return string(fields[0])
+}
+
+// writeTOC writes the table of contents as a list, rendered like any other.
+// Godoc has no nested lists, so headings of every level are listed alike.
+func (g *GodocRenderer) writeTOC(w io.Writer) {
+	if len(g.contents) == 0 {
+		return
+	}
+	list := blackfriday.NewNode(blackfriday.List)
+	for _, node := range g.contents {
+		text := blackfriday.NewNode(blackfriday.Text)
+		text.Literal = headingText(node)
+		para := blackfriday.NewNode(blackfriday.Paragraph)
+		para.AppendChild(text)
+		item := blackfriday.NewNode(blackfriday.Item)
+		item.AppendChild(para)
+		list.AppendChild(item)
+	}
+	g.out(w, []byte("Contents:"))
+	g.cr(w)
+	list.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return g.RenderNode(w, node, entering)
+	})
+	g.cr(w)
+}
+
+// headingText returns the plain text of a heading.
+func headingText(header *blackfriday.Node) []byte {
+	var text []byte
+	header.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if node.Type == blackfriday.Text || node.Type == blackfriday.Code {
+			text = append(text, node.Literal...)
+		}
+		return blackfriday.GoToNext
+	})
+	return text
+}
+
+// anchorHeading resolves a fragment-only link destination against the
+// headings of the document.
+func (g *GodocRenderer) anchorHeading(dest []byte) ([]byte, bool) {
+	if len(dest) < 2 || dest[0] != '#' {
+		return nil, false
+	}
+	heading, ok := g.headings[string(dest[1:])]
+	return heading, ok
+}
+
+// godocAnchor returns the anchor godoc generates for a heading, on pkg.go.dev
+// if the import path of the package is known.
+func (g *GodocRenderer) godocAnchor(heading []byte) []byte {
+	id := []byte("#hdr-")
+	for _, r := range string(heading) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			id = append(id, string(r)...)
+		} else {
+			id = append(id, '_')
+		}
+	}
+	if g.links.ImportPath == "" {
+		return id
+	}
+	return append([]byte("https://pkg.go.dev/"+g.links.ImportPath), id...)
+}
+
+// rewriteLink turns relative link destinations, which mean nothing in godoc,
+// into doc links or absolute repository URLs. Anything that can't be resolved
+// is returned untouched.
+func (g *GodocRenderer) rewriteLink(dest []byte) []byte {
+	u, err := url.Parse(string(dest))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return dest
+	}
+
+	// Doc links can't point into a package's documentation, so links to a
+	// section of a README go to the repository instead, if there is one
+	isReadme := strings.EqualFold(path.Base(u.Path), "README.md")
+	if isReadme && g.links.ImportPath != "" && !escapes(path.Join(g.links.Dir, path.Dir(u.Path))) &&
+		(u.Fragment == "" || g.links.RepoURL == "") {
+		if u.Fragment != "" {
+			g.warn("link to %s loses its fragment #%s in godoc", dest, u.Fragment)
+		}
+		return []byte("[" + path.Join(g.links.ImportPath, path.Dir(u.Path)) + "]")
+	}
+
+	if g.links.RepoURL == "" {
+		return dest
+	}
+	file := path.Join(g.links.Dir, u.Path)
+	if escapes(file) {
+		return dest
+	}
+	branch := g.links.Branch
+	if branch == "" {
+		branch = "master"
+	}
+	abs := strings.TrimSuffix(g.links.RepoURL, "/") + "/blob/" + branch + "/" + file
+	if u.RawQuery != "" {
+		abs += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		abs += "#" + u.Fragment
+	}
+	return []byte(abs)
+}
+
+// escapes returns true if the cleaned relative path file points outside the
+// directory it's relative to.
+func escapes(file string) bool {
+	return file == ".." || strings.HasPrefix(file, "../")
+}
+
+// directive handles HTML comments that control godoc output. Everything
+// between <!-- godoc:skip --> and <!-- godoc:end --> is skipped, the text of
+// <!-- godoc:only text --> is rendered as a paragraph that only appears in
+// godoc, and the lines following <!-- godoc:raw are copied verbatim into the
+// package comment. Any other HTML is dropped. Inline, in the middle of a
+// paragraph, the text of only is rendered as part of the paragraph, and raw
+// is dropped.
+func (g *GodocRenderer) directive(w io.Writer, html []byte, inline bool) {
+	html = bytes.TrimSpace(html)
+	if !bytes.HasPrefix(html, []byte("<!--")) || !bytes.HasSuffix(html, []byte("-->")) {
+		return
+	}
+	body := bytes.TrimSpace(html[len("<!--") : len(html)-len("-->")])
+	if !bytes.HasPrefix(body, []byte("godoc:")) {
+		return
+	}
+	name := body[len("godoc:"):]
+	var text []byte
+	if i := bytes.IndexAny(name, " \t\n"); i >= 0 {
+		name, text = name[:i], name[i:]
+	}
+
+	switch string(name) {
+	case "skip":
+		g.skipping = true
+	case "end":
+		g.skipping = false
+	case "only":
+		if g.skipping {
+			return
+		}
+		if inline {
+			g.out(w, bytes.Join(bytes.Fields(text), space))
+			return
+		}
+		lines := bytes.Split(bytes.TrimSpace(text), nl)
+		for idx, line := range lines {
+			if idx > 0 {
+				g.cr(w)
+			}
+			g.out(w, bytes.TrimSpace(line))
+		}
+		g.cr(w)
+		g.cr(w)
+	case "raw":
+		if g.skipping || inline {
+			return
+		}
+		// The first line belongs to the directive itself
+		if i := bytes.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		} else {
+			text = nil
+		}
+		s := bufio.NewScanner(bytes.NewBuffer(bytes.TrimRight(text, " \t\n")))
+		for s.Scan() {
+			if b := bytes.TrimRight(s.Bytes(), " \t"); len(b) > 0 {
+				g.out(w, b)
+			}
+			g.cr(w)
+		}
+		g.cr(w)
+	}
+}
+
 func (g *GodocRenderer) out(w io.Writer, text []byte) {
 	if g.newline && len(text) > 0 && string(text) != "//" && string(text) != "\n" {
 		w.Write(space)
@@ -230,22 +698,130 @@ func (g *GodocRenderer) cr(w io.Writer) {
 	}
 }
 
-func (g *GodocRenderer) blockCode(out io.Writer, text []byte, lang string) {
+// tabWidth is the number of spaces leading tabs are expanded to in code
+// blocks, other than Go and shell ones.
+const tabWidth = 4
+
+// shellLangs are the languages of code blocks that are kept verbatim, as
+// prompts and tabs matter to readers copying commands out of them.
+var shellLangs = map[string]bool{
+	"bash":          true,
+	"console":       true,
+	"sh":            true,
+	"shell":         true,
+	"shell-session": true,
+	"zsh":           true,
+}
+
+func (g *GodocRenderer) blockCode(out io.Writer, text []byte, info string) {
+	lang := strings.ToLower(infoLang(info))
+	for _, skip := range g.skipLangs {
+		if strings.EqualFold(skip, lang) {
+			return
+		}
+	}
+	goCode := isGo(info)
+	if g.formatCode && goCode {
+		text = g.gofmt(text)
+	}
+	if g.captions && lang != "" && !goCode {
+		g.out(out, []byte("Language: "+infoLang(info)))
+		g.cr(out)
+		g.cr(out)
+	}
+
 	s := bufio.NewScanner(bytes.NewBuffer(text))
 	for s.Scan() {
 		b := s.Bytes()
+		if !goCode && !shellLangs[lang] {
+			b = expandTabs(b)
+		}
 		if len(b) > 0 {
 			g.out(out, indent)
-			g.out(out, s.Bytes())
+			g.out(out, b)
 		}
 		g.cr(out)
 	}
 	g.cr(out)
 }
 
+// expandTabs replaces the tabs that line starts with by spaces, so that code
+// indented with a mix of both lines up.
+func expandTabs(line []byte) []byte {
+	n := 0
+	for n < len(line) && (line[n] == '\t' || line[n] == ' ') {
+		n++
+	}
+	if bytes.IndexByte(line[:n], '\t') < 0 {
+		return line
+	}
+	var lead []byte
+	for _, c := range line[:n] {
+		if c == '\t' {
+			// Tab to the next stop, rather than adding a fixed width
+			lead = append(lead, bytes.Repeat(space, tabWidth-len(lead)%tabWidth)...)
+		} else {
+			lead = append(lead, c)
+		}
+	}
+	return append(lead, line[n:]...)
+}
+
+// isGo returns true if the info string of a fenced code block says it's Go.
+func isGo(info string) bool {
+	lang := strings.ToLower(infoLang(info))
+	return lang == "go" || lang == "golang"
+}
+
+// infoLang returns the language of a fenced code block's info string.
+func infoLang(info string) string {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// gofmt formats a Go snippet. format.Source accepts whole files as well as
+// lists of declarations or statements, the latter by wrapping them in a
+// function body. Snippets that still don't parse are reported and returned
+// untouched.
+func (g *GodocRenderer) gofmt(code []byte) []byte {
+	formatted, err := format.Source(code)
+	if err != nil {
+		first, _ := splitLine(bytes.TrimSpace(code))
+		g.warn("could not gofmt code block starting %q: %v", first, err)
+		return code
+	}
+	return formatted
+}
+
+// warn reports a problem with the document, if anyone is listening.
+func (g *GodocRenderer) warn(format string, args ...interface{}) {
+	if g.warnf != nil {
+		g.warnf(format, args...)
+	}
+}
+
 // DocumentHeader writes the beginning of the package documentation.
 func (g *GodocRenderer) DocumentHeader(out *bytes.Buffer) {
-	out.WriteString("// Package " + g.pkg + " is the ")
+	switch {
+	case g.synopsis != "":
+		out.WriteString("// ")
+		if !strings.HasPrefix(g.synopsis, "Package ") {
+			out.WriteString("Package " + g.pkg + " ")
+		}
+		g.out(out, []byte(g.synopsis))
+	case g.title != "":
+		out.WriteString("// Package " + g.pkg + " is the ")
+		g.out(out, []byte(strings.TrimSuffix(g.title, ".")+"."))
+	default:
+		out.WriteString("// Package " + g.pkg + " is the ")
+		return
+	}
+	g.pkgHeaderWritten = true
+	g.cr(out)
+	g.cr(out)
 }
 
 // DocumentFooter writes the end of the package documentation
@@ -16,10 +16,13 @@ package render
 
 import (
 	"bytes"
+	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/russross/blackfriday"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGodocCTor(t *testing.T) {
@@ -81,3 +84,227 @@ func TestMixedMessages(t *testing.T) {
 	expected := "// Package anything is the This thing happens\n// **after** a Code Block\n//\n//\npackage anything\n"
 	assert.Equal(t, expected, string(output))
 }
+
+func TestCodeAtLineBreak(t *testing.T) {
+	md := []byte("Intro.\n\nRun it with `-html`\nthe way `go doc`\ndoes, or `-text` too.\n")
+	out, err := Generate(md, Options{Package: "pkg"})
+	require.NoError(t, err)
+	assert.Equal(t, "// Package pkg is the Intro.\n//\n// Run it with -html\n// the way go doc\n// does, or -text too.\npackage pkg\n", string(out))
+}
+
+func TestRewriteLink(t *testing.T) {
+	g := &GodocRenderer{
+		links: Links{
+			ImportPath: "example.com/mod/pkg",
+			Dir:        "pkg",
+			RepoURL:    "https://github.com/example/mod/",
+			Branch:     "main",
+		},
+	}
+
+	tests := map[string]string{
+		"./config/README.md":    "[example.com/mod/pkg/config]",
+		"../other/README.md":    "[example.com/mod/other]",
+		"examples/main.go":      "https://github.com/example/mod/blob/main/pkg/examples/main.go",
+		"../LICENSE.txt#L3":     "https://github.com/example/mod/blob/main/LICENSE.txt#L3",
+		"../../outside.go":      "../../outside.go",
+		"../../../x/README.md":  "../../../x/README.md",
+		"../README.md#usage":    "https://github.com/example/mod/blob/main/README.md#usage",
+		"https://golang.org":    "https://golang.org",
+		"/absolute/path":        "/absolute/path",
+		"mailto:me@example.com": "mailto:me@example.com",
+		"#usage":                "#usage",
+	}
+	for in, expected := range tests {
+		assert.Equal(t, expected, string(g.rewriteLink([]byte(in))), in)
+	}
+}
+
+func TestRewriteLink_NoRepo(t *testing.T) {
+	g := &GodocRenderer{}
+	assert.Equal(t, "./config/README.md", string(g.rewriteLink([]byte("./config/README.md"))))
+	assert.Equal(t, "examples/main.go", string(g.rewriteLink([]byte("examples/main.go"))))
+}
+
+func TestRewriteLink_ReadmeFragment(t *testing.T) {
+	var warnings []string
+	g := &GodocRenderer{
+		links: Links{ImportPath: "example.com/mod"},
+		warnf: func(format string, args ...interface{}) {
+			warnings = append(warnings, fmt.Sprintf(format, args...))
+		},
+	}
+	assert.Equal(t, "[example.com/mod/config]", string(g.rewriteLink([]byte("config/README.md#usage"))))
+	assert.Equal(t, []string{"link to config/README.md#usage loses its fragment #usage in godoc"}, warnings)
+}
+
+func TestRelativeLinks(t *testing.T) {
+	md := []byte("See [config](./config/README.md) and [example](examples/main.go).\n")
+
+	renderer := GodocWithOptions(Options{
+		Package: "pkg",
+		Links: Links{
+			ImportPath: "example.com/mod",
+			RepoURL:    "https://github.com/example/mod",
+		},
+	})
+	output := blackfriday.Markdown(md, renderer, blackfriday.Options{
+		Extensions: GodocExtensions,
+	})
+
+	assert.Contains(t, string(output), "config ([example.com/mod/config])")
+	assert.Contains(t, string(output), "example (https://github.com/example/mod/blob/master/examples/main.go)")
+}
+
+func TestAnchorLinks(t *testing.T) {
+	md := []byte("Intro, [see Usage](#usage).\n\n## Usage\n\nRun it.\n")
+
+	tests := []struct {
+		opts     Options
+		expected string
+	}{
+		{Options{Package: "pkg"}, "Intro, see Usage."},
+		{Options{Package: "pkg", Anchors: AnchorHeading}, "Intro, Usage."},
+		{Options{Package: "pkg", Anchors: AnchorGodoc}, "Intro, see Usage (#hdr-Usage)."},
+		{
+			Options{Package: "pkg", Anchors: AnchorGodoc, Links: Links{ImportPath: "example.com/pkg"}},
+			"Intro, see Usage (https://pkg.go.dev/example.com/pkg#hdr-Usage).",
+		},
+	}
+	for _, tt := range tests {
+		output := blackfriday.Markdown(md, GodocWithOptions(tt.opts), blackfriday.Options{
+			Extensions: GodocExtensions,
+		})
+		assert.Contains(t, string(output), tt.expected, string(tt.opts.Anchors))
+	}
+}
+
+func TestAnchorLinks_Unresolved(t *testing.T) {
+	md := []byte("See [nothing](#nothing).\n")
+	output := blackfriday.Markdown(md, Godoc("pkg", false), blackfriday.Options{
+		Extensions: GodocExtensions,
+	})
+	assert.Contains(t, string(output), "See nothing (#nothing).")
+}
+
+func TestGodocAnchor(t *testing.T) {
+	g := &GodocRenderer{}
+	assert.Equal(t, "#hdr-Projects_using_md_to_godoc", string(g.godocAnchor([]byte("Projects using md-to-godoc"))))
+}
+
+func TestDirectives(t *testing.T) {
+	md := []byte(`Intro.
+
+<!-- godoc:skip -->
+Hidden paragraph.
+<!-- godoc:end -->
+
+Visible.
+
+<!-- godoc:only
+Only in godoc.
+-->
+
+<!-- godoc:raw
+  raw line
+-->
+
+<!-- not a directive -->
+
+<p>HTML</p>
+
+Outro.
+`)
+	output := blackfriday.Markdown(md, Godoc("pkg", false), blackfriday.Options{
+		Extensions: GodocExtensions,
+	})
+
+	out := string(output)
+	assert.NotContains(t, out, "Hidden")
+	assert.NotContains(t, out, "directive")
+	assert.NotContains(t, out, "HTML")
+	assert.Contains(t, out, "// Visible.\n")
+	assert.Contains(t, out, "// Only in godoc.\n")
+	assert.Contains(t, out, "//   raw line\n")
+	assert.Contains(t, out, "// Outro.\n")
+}
+
+func TestDirectives_Inline(t *testing.T) {
+	md := []byte("Intro.\n\nText <!-- godoc:only in godoc --> and <!-- godoc:raw dropped -->the rest.\n\nOutro.\n")
+	output := blackfriday.Markdown(md, Godoc("pkg", false), blackfriday.Options{
+		Extensions: GodocExtensions,
+	})
+
+	assert.Contains(t, string(output), "// Text in godoc and the rest.\n//\n// Outro.\n")
+}
+
+func TestDirectives_SkipBlock(t *testing.T) {
+	md := []byte("Intro.\n\n<!-- godoc:skip -->\n\n## Contributing\n\nPlease do.\n\n<!-- godoc:end -->\n\nOutro.\n")
+	output := blackfriday.Markdown(md, Godoc("pkg", false), blackfriday.Options{
+		Extensions: GodocExtensions,
+	})
+
+	assert.NotContains(t, string(output), "Contributing")
+	assert.NotContains(t, string(output), "Please do.")
+	assert.Contains(t, string(output), "Outro.")
+}
+
+func TestTOC(t *testing.T) {
+	md := "# Title\n\nIntro.\n\n## Usage\n\nText.\n\n### Flags\n\nMore.\n\n## Contributing\n\nPlease do.\n"
+	out, err := Generate([]byte(md), Options{
+		Package:  "pkg",
+		TOC:      true,
+		Sections: SectionFilter{Exclude: []*regexp.Regexp{regexp.MustCompile("^Contributing$")}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `// Package pkg is the Title.
+//
+// Contents:
+// • Usage
+//
+// • Flags
+//
+// Intro.
+//
+// # Usage
+//
+// Text.
+//
+// # Flags
+//
+// More.
+package pkg
+`, string(out))
+}
+
+func TestTOC_Marker(t *testing.T) {
+	md := "# Title\n\n<!-- toc -->\n\n- [Usage](#usage)\n- [Stale](#stale)\n\n<!-- tocstop -->\n\n## Usage\n\nText.\n"
+	out, err := Generate([]byte(md), Options{Package: "pkg", TOC: true})
+	require.NoError(t, err)
+	assert.Equal(t, `// Package pkg is the Title.
+//
+// Contents:
+// • Usage
+//
+// # Usage
+//
+// Text.
+package pkg
+`, string(out))
+}
+
+func TestTOC_MarkerWithoutStop(t *testing.T) {
+	md := "# Title\n\n<!-- toc -->\n- [Usage](#usage)\n\n## Usage\n\nText.\n\n<!-- toc -->\n\n- Kept\n"
+	out, err := Generate([]byte(md), Options{Package: "pkg", TOC: true, Synopsis: "does things."})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "// Package pkg does things.\n//\n// Contents:\n// • Usage\n//\n// # Usage\n")
+	assert.Contains(t, string(out), "Kept")
+}
+
+func TestTOC_Off(t *testing.T) {
+	md := "Intro.\n\n<!-- toc -->\n\n- [Usage](#usage)\n\n## Usage\n\nText.\n"
+	out, err := Generate([]byte(md), Options{Package: "pkg"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "Contents:")
+	assert.Contains(t, string(out), "• Usage")
+}
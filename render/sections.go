@@ -0,0 +1,105 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// SectionFilter selects which sections of a document end up in godoc. A
+// section is a heading and everything up to the next heading of the same or
+// higher level.
+type SectionFilter struct {
+	// Include, if not empty, keeps only the sections whose heading matches
+	// one of the patterns, along with anything before the first heading.
+	Include []*regexp.Regexp
+	// Exclude removes the sections whose heading matches any of the patterns.
+	Exclude []*regexp.Regexp
+}
+
+// SectionPattern compiles a pattern for matching heading text. A pattern
+// wrapped in slashes, such as /^Install/, is a regular expression. Anything
+// else matches the whole heading text, ignoring case.
+func SectionPattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return regexp.Compile(pattern[1 : len(pattern)-1])
+	}
+	return regexp.Compile("(?i)^" + regexp.QuoteMeta(strings.TrimSpace(pattern)) + "$")
+}
+
+// Empty returns true if the filter doesn't remove anything.
+func (f SectionFilter) Empty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Filter removes the sections of the document that aren't selected by f. A
+// heading at the very start of the document is its title and is always kept,
+// along with the introduction that follows it.
+func (f SectionFilter) Filter(doc *blackfriday.Node) {
+	if f.Empty() {
+		return
+	}
+
+	type section struct {
+		level    int
+		included bool
+		excluded bool
+	}
+	// The document itself is the outermost section
+	stack := []section{{included: len(f.Include) == 0}}
+	seenHeading := false
+
+	node := doc.FirstChild
+	if node != nil && node.Type == blackfriday.Header {
+		// A leading heading is the title of the document, which godoc uses
+		// for the package synopsis. It's kept along with the introduction
+		// that follows it.
+		node = node.Next
+	}
+	for node != nil {
+		next := node.Next
+		if node.Type == blackfriday.Header {
+			for len(stack) > 1 && stack[len(stack)-1].level >= node.Level {
+				stack = stack[:len(stack)-1]
+			}
+			parent := stack[len(stack)-1]
+			text := string(headingText(node))
+			stack = append(stack, section{
+				level:    node.Level,
+				included: parent.included || matchAny(f.Include, text),
+				excluded: parent.excluded || matchAny(f.Exclude, text),
+			})
+			seenHeading = true
+		}
+
+		current := stack[len(stack)-1]
+		if current.excluded || (seenHeading && !current.included) {
+			node.Unlink()
+		}
+		node = next
+	}
+}
+
+func matchAny(patterns []*regexp.Regexp, text string) bool {
+	for _, p := range patterns {
+		if p.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
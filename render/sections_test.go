@@ -0,0 +1,120 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"testing"
+
+	"github.com/russross/blackfriday"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sectionsDoc = `# Title
+
+Intro.
+
+## Installation
+
+Install it.
+
+### From source
+
+Build it.
+
+## Usage
+
+Use it.
+
+### Advanced
+
+Really use it.
+
+## License
+
+MIT.
+`
+
+func filterSections(t *testing.T, include, exclude []string) string {
+	var f SectionFilter
+	for _, p := range include {
+		re, err := SectionPattern(p)
+		require.NoError(t, err)
+		f.Include = append(f.Include, re)
+	}
+	for _, p := range exclude {
+		re, err := SectionPattern(p)
+		require.NoError(t, err)
+		f.Exclude = append(f.Exclude, re)
+	}
+
+	ast := blackfriday.Parse([]byte(sectionsDoc), blackfriday.Options{
+		Extensions: GodocExtensions,
+	})
+	f.Filter(ast)
+	return string(Godoc("pkg", false).Render(ast))
+}
+
+func TestSectionPattern(t *testing.T) {
+	re, err := SectionPattern("usage")
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("Usage"))
+	assert.False(t, re.MatchString("Advanced usage"))
+
+	re, err = SectionPattern("/usage$/")
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("Advanced usage"))
+
+	_, err = SectionPattern("/(/")
+	assert.Error(t, err)
+}
+
+func TestSectionFilter_Exclude(t *testing.T) {
+	out := filterSections(t, nil, []string{"Installation", "License"})
+
+	assert.Contains(t, out, "Package pkg is the Title.")
+	assert.Contains(t, out, "Intro.")
+	assert.Contains(t, out, "Use it.")
+	assert.Contains(t, out, "Really use it.")
+	assert.NotContains(t, out, "Install it.")
+	assert.NotContains(t, out, "Build it.")
+	assert.NotContains(t, out, "MIT.")
+}
+
+func TestSectionFilter_ExcludeSubsection(t *testing.T) {
+	out := filterSections(t, nil, []string{"From source"})
+
+	assert.Contains(t, out, "Install it.")
+	assert.NotContains(t, out, "Build it.")
+	assert.Contains(t, out, "Use it.")
+}
+
+func TestSectionFilter_Include(t *testing.T) {
+	out := filterSections(t, []string{"Usage"}, nil)
+
+	assert.Contains(t, out, "Package pkg is the Title.")
+	assert.Contains(t, out, "Intro.")
+	assert.Contains(t, out, "Use it.")
+	assert.Contains(t, out, "Really use it.")
+	assert.NotContains(t, out, "Install it.")
+	assert.NotContains(t, out, "MIT.")
+}
+
+func TestSectionFilter_IncludeAndExclude(t *testing.T) {
+	out := filterSections(t, []string{"Usage"}, []string{"Advanced"})
+
+	assert.Contains(t, out, "Use it.")
+	assert.NotContains(t, out, "Really use it.")
+}
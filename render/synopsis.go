@@ -0,0 +1,136 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday"
+)
+
+// DefaultSynopsisLimit is the length of synopsis above which it's reported as
+// too long, unless Options.SynopsisLimit says otherwise.
+const DefaultSynopsisLimit = 100
+
+// SynopsisSource says where the package synopsis, the first sentence of the
+// documentation, comes from. A synopsis in the front matter always wins.
+type SynopsisSource string
+
+const (
+	// SynopsisHeading makes the leading heading of the document the synopsis,
+	// "Package pkg is the Title.", or failing that its first paragraph.
+	SynopsisHeading SynopsisSource = "heading"
+	// SynopsisParagraph makes the first paragraph with any text in it the
+	// synopsis, leaving out the leading heading.
+	SynopsisParagraph SynopsisSource = "paragraph"
+	// SynopsisFrontMatter expects the synopsis to be set in the front matter,
+	// and reports it if it isn't.
+	SynopsisFrontMatter SynopsisSource = "front-matter"
+)
+
+var (
+	urlPattern      = regexp.MustCompile(`https?://|www\.`)
+	markdownPattern = regexp.MustCompile("\\]\\(|!\\[|\\[!|\\*\\*|__|`|<[a-zA-Z/!]")
+)
+
+// paragraphSynopsis takes the synopsis from the first paragraph of the
+// document with any text in it, which is then left out of the rest.
+func (g *GodocRenderer) paragraphSynopsis(ast *blackfriday.Node) {
+	for node := ast.FirstChild; node != nil; node = node.Next {
+		if node.Type != blackfriday.Paragraph {
+			continue
+		}
+		if text := paragraphText(node); text != "" {
+			g.synopsis = lowerFirst(text)
+			g.synopsisNode = node
+			return
+		}
+	}
+}
+
+// paragraphText returns the plain text of a paragraph on one line, leaving
+// out images such as badges.
+func paragraphText(para *blackfriday.Node) string {
+	var text []byte
+	para.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		switch node.Type {
+		case blackfriday.Image:
+			return blackfriday.SkipChildren
+		case blackfriday.Text, blackfriday.Code:
+			text = append(text, node.Literal...)
+		case blackfriday.Softbreak, blackfriday.Hardbreak:
+			text = append(text, ' ')
+		}
+		return blackfriday.GoToNext
+	})
+	return strings.Join(strings.Fields(string(text)), " ")
+}
+
+// lowerFirst lowercases the first letter of text, so that it reads on from
+// "Package pkg", unless it's part of an acronym such as HTTP.
+func lowerFirst(text string) string {
+	if text == "" {
+		return text
+	}
+	first, size := utf8.DecodeRuneInString(text)
+	next, _ := utf8.DecodeRuneInString(text[size:])
+	if unicode.IsUpper(next) {
+		return text
+	}
+	return string(unicode.ToLower(first)) + text[size:]
+}
+
+// lintSynopsis reports problems with the synopsis of the package
+// documentation in src, the way pkg.go.dev extracts it: too long, or with
+// URLs or markdown left in it, or not ending in a period.
+func lintSynopsis(src []byte, opts Options) {
+	if opts.Warnf == nil {
+		return
+	}
+	if opts.SynopsisFrom == SynopsisFrontMatter && opts.Synopsis == "" {
+		opts.Warnf("no synopsis in the front matter")
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil || f.Doc == nil {
+		return
+	}
+	synopsis := new(doc.Package).Synopsis(f.Doc.Text())
+	if synopsis == "" {
+		return
+	}
+
+	limit := opts.SynopsisLimit
+	if limit == 0 {
+		limit = DefaultSynopsisLimit
+	}
+	if n := utf8.RuneCountInString(synopsis); n > limit {
+		opts.Warnf("synopsis %q is %d characters long, more than %d", synopsis, n, limit)
+	}
+	if urlPattern.MatchString(synopsis) {
+		opts.Warnf("synopsis %q contains a URL", synopsis)
+	}
+	if markdownPattern.MatchString(synopsis) {
+		opts.Warnf("synopsis %q contains markdown", synopsis)
+	}
+	if !strings.HasSuffix(synopsis, ".") {
+		opts.Warnf("synopsis %q doesn't end with a period", synopsis)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// synopsisWarnings generates md and returns the warnings about it.
+func synopsisWarnings(t *testing.T, md string, opts Options) []string {
+	var warnings []string
+	opts.Package = "pkg"
+	opts.Badges = true
+	opts.Warnf = func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	_, err := Generate([]byte(md), opts)
+	require.NoError(t, err)
+	return warnings
+}
+
+func TestLintSynopsis(t *testing.T) {
+	tests := []struct {
+		md       string
+		opts     Options
+		warnings []string
+	}{
+		{"# Title\n\nText.\n", Options{}, nil},
+		{
+			"# Title [Build](https://travis-ci.org/x)\n\nText.\n",
+			Options{},
+			[]string{
+				`synopsis "Package pkg is the Title Build (https://travis-ci.org/x)." contains a URL`,
+			},
+		},
+		{
+			"# Title [![Godoc][godoc-img]][godoc]\n",
+			Options{},
+			[]string{
+				`synopsis "Package pkg is the Title [![Godoc][godoc-img]][godoc]." contains markdown`,
+			},
+		},
+		{
+			"Does things without stopping\n",
+			Options{},
+			[]string{
+				`synopsis "Package pkg is the Does things without stopping" doesn't end with a period`,
+			},
+		},
+		{
+			"# " + strings.Repeat("Long ", 10) + "\n",
+			Options{SynopsisLimit: 40},
+			[]string{
+				`synopsis "Package pkg is the Long Long Long Long Long Long Long Long Long Long." is 69 characters long, more than 40`,
+			},
+		},
+		{"# Title\n", Options{SynopsisFrom: SynopsisFrontMatter}, []string{"no synopsis in the front matter"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.warnings, synopsisWarnings(t, tt.md, tt.opts), tt.md)
+	}
+}
+
+func TestSynopsisFromParagraph(t *testing.T) {
+	md := "# Title\n\n[![Build Status](https://travis-ci.org/x.svg)](https://travis-ci.org/x)\n\nConverts markdown\nto godoc.\n\nMore.\n"
+	out, err := Generate([]byte(md), Options{Package: "pkg", SynopsisFrom: SynopsisParagraph})
+	require.NoError(t, err)
+	assert.Equal(t, "// Package pkg converts markdown to godoc.\n//\n// More.\npackage pkg\n", string(out))
+}
+
+func TestLowerFirst(t *testing.T) {
+	assert.Equal(t, "converts things.", lowerFirst("Converts things."))
+	assert.Equal(t, "HTTP things.", lowerFirst("HTTP things."))
+	assert.Equal(t, "", lowerFirst(""))
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Expand runs a markdown document through text/template before it's parsed,
+// with data as the value of dot. Besides the usual functions, templates can
+// include other files relative to dir:
+//
+//	{{include "example_test.go"}}
+//	{{include "example_test.go" "basic"}}
+//
+// The latter only includes the lines between the region markers
+// "// region basic" and "// endregion basic", with their common indentation
+// removed.
+func Expand(input []byte, dir string, data interface{}) ([]byte, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Funcs(template.FuncMap{
+		"include": func(file string, region ...string) (string, error) {
+			return include(filepath.Join(dir, file), region...)
+		},
+	}).Parse(string(input))
+	if err != nil {
+		return nil, err
+	}
+	var buff bytes.Buffer
+	if err := tmpl.Execute(&buff, data); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// include returns the contents of file, or of the named region of it.
+func include(file string, region ...string) (string, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	switch len(region) {
+	case 0:
+		return strings.TrimRight(string(contents), "\n"), nil
+	case 1:
+	default:
+		return "", fmt.Errorf("include takes a file and at most one region, got %d regions", len(region))
+	}
+
+	var lines []string
+	inside, found := false, false
+	s := bufio.NewScanner(bytes.NewReader(contents))
+	for s.Scan() {
+		line := s.Text()
+		switch marker, name := regionMarker(line); {
+		case marker == "region" && name == region[0]:
+			inside, found = true, true
+		case marker == "endregion" && name == region[0]:
+			inside = false
+		case inside:
+			lines = append(lines, line)
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("no region %q in %v", region[0], file)
+	}
+	return strings.Join(dedent(lines), "\n"), nil
+}
+
+// regionMarker returns the marker, region or endregion, and the name of the
+// region if line is a comment marking the start or end of one.
+func regionMarker(line string) (marker, name string) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+	fields := strings.Fields(strings.TrimLeft(line, "/# "))
+	if len(fields) != 2 || (fields[0] != "region" && fields[0] != "endregion") {
+		return "", ""
+	}
+	return fields[0], fields[1]
+}
+
+// dedent removes the leading whitespace that all non-blank lines share.
+func dedent(lines []string) []string {
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, prefix)
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Aiden Scandella
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleSource = `package foo_test
+
+func Example() {
+	// region basic
+	if ok {
+		run()
+	}
+	// endregion basic
+}
+`
+
+func TestExpand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-to-godoc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "example_test.go"), []byte(exampleSource), 0644))
+
+	md := "Import {{.ImportPath}}:\n\n```go\n{{include \"example_test.go\" \"basic\"}}\n```\n"
+	out, err := Expand([]byte(md), dir, map[string]string{"ImportPath": "example.com/foo"})
+	require.NoError(t, err)
+	assert.Equal(t, "Import example.com/foo:\n\n```go\nif ok {\n\trun()\n}\n```\n", string(out))
+
+	out, err = Expand([]byte(`{{include "example_test.go"}}`), dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, exampleSource[:len(exampleSource)-1], string(out))
+}
+
+func TestExpand_Errors(t *testing.T) {
+	tests := map[string]string{
+		"{{.Missing}}":                          `map has no entry for key "Missing"`,
+		"{{":                                    "unclosed action",
+		`{{include "nope.go"}}`:                 "no such file",
+		`{{include "template_test.go" "nope"}}`: `no region "nope"`,
+	}
+	for md, want := range tests {
+		_, err := Expand([]byte(md), ".", map[string]string{})
+		if assert.Error(t, err, md) {
+			assert.Contains(t, err.Error(), want, md)
+		}
+	}
+}